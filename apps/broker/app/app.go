@@ -2,6 +2,8 @@ package app
 
 import (
 	"github.com/flinkcoin/mono/apps/broker/internal/networking"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"log/slog"
 )
 
 type App struct {
@@ -11,3 +13,7 @@ type App struct {
 func NewApp(host *networking.Host) *App {
 	return &App{Host: host}
 }
+
+func NewLogger() *slog.Logger {
+	return base.Log
+}
@@ -4,11 +4,12 @@
 package app
 
 import (
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
 	"github.com/flinkcoin/mono/apps/broker/internal/networking"
 	"github.com/google/wire"
 )
 
 func Init() *App {
-	wire.Build(networking.NewHost, NewApp)
+	wire.Build(NewLogger, config.NewConfig, networking.NewHost, NewApp)
 	return nil
 }
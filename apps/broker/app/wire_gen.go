@@ -7,13 +7,16 @@
 package app
 
 import (
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
 	"github.com/flinkcoin/mono/apps/broker/internal/networking"
 )
 
 // Injectors from wire.go:
 
 func Init() *App {
-	host := networking.NewHost()
+	logger := NewLogger()
+	configConfig := config.NewConfig(logger)
+	host := networking.NewHost(configConfig)
 	app := NewApp(host)
 	return app
 }
@@ -1,12 +1,109 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"github.com/caarlos0/env/v11"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 	"log/slog"
+	"net"
 	"sync"
 	"time"
 )
 
+// DuplicateConnectionPolicy decides which connection to keep when a peer is
+// connected to us more than once. PreferQUIC and PreferTCP keep a connection
+// over the preferred transport, and KeepFirst keeps the connection that was
+// established first. Connections that tie, including ones opened too close
+// together for both sides to agree which came first, are settled in favour
+// of the one dialed by the lower peer ID, so that both sides keep the same
+// connection.
+type DuplicateConnectionPolicy string
+
+const (
+	KeepBoth   DuplicateConnectionPolicy = "keep-both"
+	PreferQUIC DuplicateConnectionPolicy = "prefer-quic"
+	PreferTCP  DuplicateConnectionPolicy = "prefer-tcp"
+	KeepFirst  DuplicateConnectionPolicy = "keep-first"
+)
+
+func (p *DuplicateConnectionPolicy) UnmarshalText(text []byte) error {
+	return parseEnum(p, text, KeepBoth, PreferQUIC, PreferTCP, KeepFirst)
+}
+
+// InitialDialOrder decides whether static peers or bootnodes are dialed first
+// at startup.
+type InitialDialOrder string
+
+const (
+	StaticFirst    InitialDialOrder = "static-first"
+	BootnodesFirst InitialDialOrder = "bootnodes-first"
+	DialParallel   InitialDialOrder = "parallel"
+)
+
+func (o *InitialDialOrder) UnmarshalText(text []byte) error {
+	return parseEnum(o, text, StaticFirst, BootnodesFirst, DialParallel)
+}
+
+// ProtocolDeadline bounds reading and writing a single chunk on a protocol's
+// streams. A zero deadline falls back to the default.
+type ProtocolDeadline struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// DiscoveryBackend finds peers through a custom mechanism, e.g. a service
+// registry such as Consul or etcd. The host starts it on Init, dials every
+// peer sent on Peers and stops it on shutdown.
+type DiscoveryBackend interface {
+	Start(ctx context.Context) error
+	Stop() error
+	Peers() <-chan peer.AddrInfo
+}
+
+// KeyMissingBehavior decides what happens when the private key file does not
+// exist.
+type KeyMissingBehavior string
+
+const (
+	KeyGenerate           KeyMissingBehavior = "generate"
+	KeyGenerateAndPersist KeyMissingBehavior = "generate-and-persist"
+	KeyFail               KeyMissingBehavior = "fail"
+)
+
+func (b *KeyMissingBehavior) UnmarshalText(text []byte) error {
+	return parseEnum(b, text, KeyGenerate, KeyGenerateAndPersist, KeyFail)
+}
+
+// IPFamilyPreference decides which IP family is advertised first on
+// dual-stack hosts.
+type IPFamilyPreference string
+
+const (
+	PreferBoth IPFamilyPreference = "both"
+	PreferIPv4 IPFamilyPreference = "ipv4"
+	PreferIPv6 IPFamilyPreference = "ipv6"
+)
+
+func (p *IPFamilyPreference) UnmarshalText(text []byte) error {
+	return parseEnum(p, text, PreferBoth, PreferIPv4, PreferIPv6)
+}
+
+// parseEnum sets dst to text if it is one of valid, so that a typo in an
+// enum setting fails parsing instead of silently selecting a fallback.
+func parseEnum[T ~string](dst *T, text []byte, valid ...T) error {
+	for _, v := range valid {
+		if string(text) == string(v) {
+			*dst = v
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown value %q, expected one of %q", text, valid)
+}
+
 type Config struct {
 	Home         string         `env:"HOME"`
 	Port         int            `env:"PORT" envDefault:"3000"`
@@ -16,6 +113,157 @@ type Config struct {
 	Hosts        []string       `env:"HOSTS" envSeparator:":"`
 	TempFolder   string         `env:"TEMP_FOLDER,expand" envDefault:"${HOME}/tmp"`
 	StringInts   map[string]int `env:"MAP_STRING_INT"`
+
+	// NetworkName labels all networking metrics, libp2p's included, so that
+	// several hosts in one process can be told apart.
+	NetworkName string `env:"P2P_NETWORK_NAME"`
+
+	// PrivateKeyPath holds the host's marshalled private key. Without it a
+	// new key, and so a new peer ID, is generated on every start.
+	PrivateKeyPath     string             `env:"P2P_PRIVATE_KEY_PATH"`
+	KeyMissingBehavior KeyMissingBehavior `env:"P2P_KEY_MISSING_BEHAVIOR" envDefault:"generate"`
+
+	// TCPPort and QUICPort are the local ports the host binds. QUIC is only
+	// enabled when QUICPort is set.
+	TCPPort  int `env:"P2P_TCP_PORT"`
+	QUICPort int `env:"P2P_QUIC_PORT"`
+	// HostAddress, ExternalTCPPort and ExternalQUICPort describe how the host
+	// is reachable from outside, e.g. behind a NAT with port forwarding. They
+	// only affect advertised addresses.
+	HostAddress      string `env:"P2P_HOST_ADDRESS"`
+	ExternalTCPPort  int    `env:"P2P_EXTERNAL_TCP_PORT"`
+	ExternalQUICPort int    `env:"P2P_EXTERNAL_QUIC_PORT"`
+	// IPFamilyPreference lists addresses of this family first in the
+	// addresses we advertise. Both families are still advertised.
+	IPFamilyPreference IPFamilyPreference `env:"P2P_IP_FAMILY_PREFERENCE" envDefault:"both"`
+	// SOCKS5Proxy is a host:port, e.g. a local Tor client, that all outbound
+	// TCP connections are dialed through. SOCKS5 cannot carry UDP, so QUIC is
+	// disabled while it is set, and UDP-based peer discovery would bypass the
+	// proxy; use bootnodes or static peers instead. DNS names in multiaddrs
+	// are resolved by the proxy, never locally.
+	SOCKS5Proxy string `env:"P2P_SOCKS5_PROXY"`
+	// EnableNATPortMap asks the gateway for a port mapping via UPnP or NAT-PMP.
+	EnableNATPortMap bool `env:"P2P_NAT_PORT_MAP" envDefault:"true"`
+
+	// MaxInboundPeers and MaxOutboundPeers cap the number of peers per
+	// connection direction. Zero means no limit.
+	MaxInboundPeers  int `env:"P2P_MAX_INBOUND_PEERS"`
+	MaxOutboundPeers int `env:"P2P_MAX_OUTBOUND_PEERS"`
+	// MaxPeerCount caps the total number of connected peers, counting slots
+	// reserved with ReserveSlot. Zero means no limit.
+	MaxPeerCount int `env:"P2P_MAX_PEER_COUNT"`
+
+	// Bootnodes are multiaddrs, including the /p2p/ peer ID, dialed at
+	// startup to join the network. Connected bootnodes are protected like
+	// static peers.
+	Bootnodes []string `env:"P2P_BOOTNODES" envSeparator:","`
+	// StaticPeers are trusted peer multiaddrs, including the /p2p/ peer ID,
+	// dialed at startup alongside the bootnodes. Once connected they are
+	// protected from idle disconnects and connection trimming.
+	StaticPeers []string `env:"P2P_STATIC_PEERS" envSeparator:","`
+	// InitialDialOrder decides whether startup waits for the static peer
+	// dials before dialing the bootnodes, the other way round, or dials both
+	// at once.
+	InitialDialOrder InitialDialOrder `env:"P2P_INITIAL_DIAL_ORDER" envDefault:"parallel"`
+	// RegistryURL points to an HTTP peer registry serving a JSON array of
+	// peer multiaddrs, fetched every RegistryRefreshInterval and dialed.
+	RegistryURL             string        `env:"P2P_REGISTRY_URL"`
+	RegistryRefreshInterval time.Duration `env:"P2P_REGISTRY_REFRESH_INTERVAL" envDefault:"5m"`
+	// DiscoveryBackend, when set, supplies further peers to dial for the
+	// lifetime of the host.
+	DiscoveryBackend DiscoveryBackend
+	// IsolationTimeout is how long the host may go without any peers before
+	// it restarts discovery and re-dials the bootnodes. Zero disables the
+	// watchdog.
+	IsolationTimeout time.Duration `env:"P2P_ISOLATION_TIMEOUT" envDefault:"5m"`
+
+	// MaxConcurrentRequestsPerProtocol limits the outbound requests we have
+	// in flight per protocol. Zero means no limit.
+	MaxConcurrentRequestsPerProtocol int `env:"P2P_MAX_CONCURRENT_REQUESTS" envDefault:"16"`
+	// ProtocolDeadlines overrides the chunk read and write deadlines for the
+	// streams of a protocol, keyed by protocol ID, so that expensive
+	// protocols can be given longer than cheap ones.
+	ProtocolDeadlines map[string]ProtocolDeadline
+
+	// DuplicateConnectionPolicy closes redundant connections to the same
+	// peer. Both sides should use the same policy.
+	DuplicateConnectionPolicy DuplicateConnectionPolicy `env:"P2P_DUPLICATE_CONNECTION_POLICY" envDefault:"keep-both"`
+
+	// IdlePeerTimeout disconnects peers that opened or served no streams for
+	// this long. Peers protected in the connection manager are exempt. Zero
+	// disables it.
+	IdlePeerTimeout time.Duration `env:"P2P_IDLE_PEER_TIMEOUT"`
+
+	// AsymmetryWindow is how long a peer must have been dialed by us without
+	// ever dialing us back before it is reported as asymmetric. Zero disables
+	// the tracking.
+	AsymmetryWindow time.Duration `env:"P2P_ASYMMETRY_WINDOW" envDefault:"1h"`
+
+	// LatencySampleInterval is how often connected peers are pinged to
+	// sample their round-trip time. Zero disables sampling.
+	LatencySampleInterval time.Duration `env:"P2P_LATENCY_SAMPLE_INTERVAL"`
+
+	// VerboseBelowPeers raises dial failure logging from debug to warn while
+	// fewer than this many peers are connected. Zero disables it.
+	VerboseBelowPeers int `env:"P2P_VERBOSE_BELOW_PEERS"`
+
+	// MaxIngressBytesPerSec and MaxEgressBytesPerSec cap the bandwidth of
+	// our protocol streams across all peers. Traffic over the cap is slowed
+	// down, not dropped. Zero means no limit.
+	MaxIngressBytesPerSec int `env:"P2P_MAX_INGRESS_BYTES_PER_SEC"`
+	MaxEgressBytesPerSec  int `env:"P2P_MAX_EGRESS_BYTES_PER_SEC"`
+
+	// AddrDialTimeout replaces the swarm's per-address dial timeout for both
+	// remote and local addresses. It covers the whole attempt on one address,
+	// transport connect plus security and muxer handshakes. Zero keeps the
+	// swarm's defaults.
+	AddrDialTimeout time.Duration `env:"P2P_ADDR_DIAL_TIMEOUT"`
+	// HandshakeTimeout bounds the security and muxer handshakes of an
+	// outbound TCP connection once it is established, so that peers which
+	// accept the connection but never finish the handshake fail fast, however
+	// long AddrDialTimeout allows for connecting. Inbound handshakes keep
+	// libp2p's fixed accept timeout. Zero leaves them to AddrDialTimeout.
+	HandshakeTimeout time.Duration `env:"P2P_HANDSHAKE_TIMEOUT"`
+
+	// BlocklistPath points to a file of banned peer IDs and CIDRs, one per
+	// line. It is reloaded on SIGHUP.
+	BlocklistPath string `env:"P2P_BLOCKLIST_PATH"`
+
+	// MaxInboundHandshakeRate caps inbound connections accepted per second
+	// across all peers, allowing bursts of MaxInboundHandshakeBurst.
+	// Connections over the rate are rejected before the handshake. Zero means
+	// no limit.
+	MaxInboundHandshakeRate  float64 `env:"P2P_MAX_INBOUND_HANDSHAKE_RATE"`
+	MaxInboundHandshakeBurst int     `env:"P2P_MAX_INBOUND_HANDSHAKE_BURST" envDefault:"32"`
+
+	// ASNResolver maps an IP to its autonomous system number. When it is set,
+	// MaxPeersPerASN caps the peers connected from any one AS. IPs the
+	// resolver does not know are not limited.
+	ASNResolver    func(ip net.IP) (uint32, bool)
+	MaxPeersPerASN int `env:"P2P_MAX_PEERS_PER_ASN"`
+
+	// PeerEventDumpPath, when set, receives the recent peer events as
+	// newline-delimited JSON on shutdown.
+	PeerEventDumpPath string `env:"P2P_PEER_EVENT_DUMP_PATH"`
+
+	// AddrFilter, when set, is consulted for every address we dial or accept
+	// a connection from, in addition to the blocklist. Both must allow an
+	// address for the connection to proceed, unless
+	// AddrFilterOverridesBlocklist is set: then the filter alone decides for
+	// addresses in blocklisted ranges, so it can permit a range the shared
+	// blocklist bans, such as a lab's private network. Banned peer IDs stay
+	// banned either way.
+	AddrFilter                   func(addr ma.Multiaddr) bool
+	AddrFilterOverridesBlocklist bool `env:"P2P_ADDR_FILTER_OVERRIDES_BLOCKLIST"`
+
+	// InboundAdmission is consulted for every inbound connection once the
+	// remote peer is known. Returning false rejects the connection.
+	InboundAdmission func(remote ma.Multiaddr, pid peer.ID) bool
+	// OnPeerConnect and OnPeerDisconnect are called in their own goroutine
+	// when a peer connects for the first time and when its last connection
+	// closes.
+	OnPeerConnect    func(pid peer.ID, dir network.Direction)
+	OnPeerDisconnect func(pid peer.ID)
 }
 
 var (
@@ -30,7 +278,7 @@ func NewConfig(logger *slog.Logger) *Config {
 	configOnce.Do(func() {
 		cfg = &Config{}
 		if err := env.Parse(cfg); err != nil {
-			logger.Error("We have a problem with configuration!")
+			logger.Error("We have a problem with configuration!", "error", err)
 		}
 
 	})
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/caarlos0/env/v11"
+)
+
+func TestParseKeyMissingBehavior(t *testing.T) {
+	t.Setenv("P2P_KEY_MISSING_BEHAVIOR", "generate-and-persist")
+
+	cfg := &Config{}
+	if err := env.Parse(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.KeyMissingBehavior != KeyGenerateAndPersist {
+		t.Errorf("expected %q, got %q", KeyGenerateAndPersist, cfg.KeyMissingBehavior)
+	}
+}
+
+func TestParseRejectsUnknownKeyMissingBehavior(t *testing.T) {
+	t.Setenv("P2P_KEY_MISSING_BEHAVIOR", "regenerate")
+
+	if err := env.Parse(&Config{}); err == nil {
+		t.Fatal("expected an unknown P2P_KEY_MISSING_BEHAVIOR to be rejected")
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	cfg := &Config{}
+	if err := env.Parse(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.KeyMissingBehavior != KeyGenerate || cfg.DuplicateConnectionPolicy != KeepBoth ||
+		cfg.InitialDialOrder != DialParallel || cfg.IPFamilyPreference != PreferBoth {
+		t.Errorf("unexpected enum defaults: %+v", cfg)
+	}
+}
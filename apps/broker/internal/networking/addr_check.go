@@ -0,0 +1,144 @@
+package networking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// selfDialTimeout bounds a single dial to one of our own addresses.
+const selfDialTimeout = 2 * time.Second
+
+// AddressCheck is the result of checking one advertised address. Listening is
+// set when the host binds the address's transport and port itself. Mapped is
+// set when the address is instead one a bind port is expected to be reachable
+// at, through the configured external ports or a gateway NAT mapping. Dialed
+// and Reachable report whether a probe host dialed the address and reached
+// this host's peer ID over it. Error explains a failed check.
+type AddressCheck struct {
+	Addr      string
+	Listening bool
+	Mapped    bool
+	Dialed    bool
+	Reachable bool
+	Error     string
+}
+
+// VerifyAdvertisedAddresses checks every address we advertise against the
+// ports we bind and the port mappings we know of, and dials each one from a
+// separate, short-lived host, catching e.g. an external port that nothing is
+// forwarded to or that another process answers on. It must be called after
+// Init.
+func (n *Host) VerifyAdvertisedAddresses() []AddressCheck {
+	bound := make(map[string]struct{})
+	mapped := make(map[string]struct{})
+	for _, addr := range n.host.Network().ListenAddresses() {
+		bound[transportPort(addr)] = struct{}{}
+		if external, err := n.externalAddr(addr); err == nil && !external.Equal(addr) {
+			mapped[transportPort(external)] = struct{}{}
+		}
+	}
+
+	n.addrsLock.Lock()
+	natAddrs := make(map[string]struct{}, len(n.natAddrs))
+	for addr := range n.natAddrs {
+		natAddrs[addr] = struct{}{}
+	}
+	n.addrsLock.Unlock()
+
+	advertised := n.host.Addrs()
+	checks := make([]AddressCheck, 0, len(advertised))
+	for _, addr := range advertised {
+		check := AddressCheck{Addr: addr.String()}
+		_, check.Listening = bound[transportPort(addr)]
+		_, check.Mapped = mapped[transportPort(addr)]
+		if _, ok := natAddrs[addr.String()]; ok {
+			check.Mapped = true
+		}
+		if !check.Listening && !check.Mapped {
+			check.Error = fmt.Sprintf("not listening on %s", transportPort(addr))
+		}
+		checks = append(checks, check)
+	}
+
+	prober, err := n.newSelfProber()
+	if err != nil {
+		for i := range checks {
+			if checks[i].Error == "" {
+				checks[i].Error = fmt.Sprintf("self-dial not possible: %v", err)
+			}
+		}
+		return checks
+	}
+	defer prober.Close()
+
+	n.startSelfProbe(prober.ID())
+	defer n.endSelfProbe(prober.ID())
+
+	for i, addr := range advertised {
+		if checks[i].Error != "" {
+			continue
+		}
+		checks[i].Dialed = true
+		if err := n.selfDial(prober, addr); err != nil {
+			checks[i].Error = err.Error()
+		} else {
+			checks[i].Reachable = true
+		}
+	}
+
+	return checks
+}
+
+// transportPort returns the part of addr after the IP, e.g. /tcp/4001.
+func transportPort(addr ma.Multiaddr) string {
+	_, rest := ma.SplitFirst(addr)
+	if rest == nil {
+		return ""
+	}
+
+	return rest.String()
+}
+
+// newSelfProber starts a host with a throwaway identity that only dials, used
+// to reach our own addresses from the outside. libp2p registers some metrics
+// even when they are disabled, so it shares our labelled registerer.
+func (n *Host) newSelfProber() (host.Host, error) {
+	return libp2p.New(
+		libp2p.NoListenAddrs,
+		libp2p.DisableRelay(),
+		// Must come first, libp2p refuses a registerer once metrics are
+		// disabled.
+		libp2p.PrometheusRegisterer(n.metricsRegisterer()),
+		libp2p.DisableMetrics(),
+		libp2p.ResourceManager(&network.NullResourceManager{}),
+	)
+}
+
+// selfDial dials addr from prober and checks that it is this host answering,
+// not another process bound to the same port.
+func (n *Host) selfDial(prober host.Host, addr ma.Multiaddr) error {
+	ctx, cancel := context.WithTimeout(n.ctx, selfDialTimeout)
+	defer cancel()
+
+	self := n.host.ID()
+	prober.Peerstore().ClearAddrs(self)
+	prober.Peerstore().AddAddr(self, addr, peerstore.TempAddrTTL)
+	defer prober.Network().ClosePeer(self)
+
+	conn, err := prober.Network().DialPeer(network.WithForceDirectDial(ctx, "self-dial"), self)
+	if err != nil {
+		return fmt.Errorf("self-dial failed: %w", err)
+	}
+	if conn.RemotePeer() != self {
+		return fmt.Errorf("self-dial reached peer %s instead of us", conn.RemotePeer())
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package networking
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+// freeTCPPort returns a loopback TCP port that nothing listens on.
+func freeTCPPort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// forwardPort forwards connections from a new loopback port to target, like
+// a NAT port forwarding rule, and returns the forwarded port.
+func forwardPort(t *testing.T, target int) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			in, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer in.Close()
+				out, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", target))
+				if err != nil {
+					return
+				}
+				defer out.Close()
+				go func() { _, _ = io.Copy(out, in) }()
+				_, _ = io.Copy(in, out)
+			}()
+		}
+	}()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// addressChecks returns the checks of n keyed by address.
+func addressChecks(n *Host) map[string]AddressCheck {
+	checks := make(map[string]AddressCheck)
+	for _, check := range n.VerifyAdvertisedAddresses() {
+		checks[check.Addr] = check
+	}
+
+	return checks
+}
+
+func TestVerifyAdvertisedAddressesForwardedPort(t *testing.T) {
+	bind := freeTCPPort(t)
+	external := forwardPort(t, bind)
+
+	n := newTestHost(t, &config.Config{TCPPort: bind, HostAddress: "127.0.0.1", ExternalTCPPort: external})
+	checks := addressChecks(n)
+
+	forwarded, ok := checks[fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", external)]
+	if !ok {
+		t.Fatalf("expected the external address to be checked, got %v", checks)
+	}
+	if forwarded.Listening || !forwarded.Mapped || !forwarded.Dialed || !forwarded.Reachable || forwarded.Error != "" {
+		t.Errorf("expected the forwarded port to pass as mapped, got %+v", forwarded)
+	}
+
+	listen, ok := checks[fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", bind)]
+	if !ok {
+		t.Fatalf("expected the listen address to be checked, got %v", checks)
+	}
+	if !listen.Listening || !listen.Reachable || listen.Error != "" {
+		t.Errorf("expected the listen address to pass, got %+v", listen)
+	}
+}
+
+func TestVerifyAdvertisedAddressesUnforwardedPort(t *testing.T) {
+	// Advertise an external port nothing is forwarded to.
+	unused := freeTCPPort(t)
+
+	n := newTestHost(t, &config.Config{HostAddress: "127.0.0.1", ExternalTCPPort: unused})
+	checks := addressChecks(n)
+
+	mismatched, ok := checks[fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", unused)]
+	if !ok {
+		t.Fatalf("expected the external address to be checked, got %v", checks)
+	}
+	if !mismatched.Dialed || mismatched.Reachable || mismatched.Error == "" {
+		t.Errorf("expected the failed self-dial to be reported, got %+v", mismatched)
+	}
+}
+
+func TestVerifyAdvertisedAddressesOtherProcessOnPort(t *testing.T) {
+	// Something other than our host answers on the advertised port.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+	other := l.Addr().(*net.TCPAddr).Port
+
+	n := newTestHost(t, &config.Config{HostAddress: "127.0.0.1", ExternalTCPPort: other})
+	checks := addressChecks(n)
+
+	wrong, ok := checks[fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", other)]
+	if !ok {
+		t.Fatalf("expected the external address to be checked, got %v", checks)
+	}
+	if wrong.Listening || !wrong.Dialed || wrong.Reachable || wrong.Error == "" {
+		t.Errorf("expected the foreign listener to fail the check, got %+v", wrong)
+	}
+	if n.ConnectedPeerCount() != 0 {
+		t.Errorf("expected self-dials to leave no peers behind, got %d", n.ConnectedPeerCount())
+	}
+}
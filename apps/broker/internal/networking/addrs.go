@@ -0,0 +1,199 @@
+package networking
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/network"
+	basichost "github.com/libp2p/go-libp2p/p2p/host/basic"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// listenAddrs returns the local addresses the host binds to, on both IP
+// families so dual-stack nodes can be reached over IPv6 as well.
+func (n *Host) listenAddrs() []string {
+	cfg := n.config()
+	addrs := []string{
+		fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", cfg.TCPPort),
+		fmt.Sprintf("/ip6/::/tcp/%d", cfg.TCPPort),
+	}
+	if cfg.QUICPort > 0 && cfg.SOCKS5Proxy == "" {
+		addrs = append(addrs,
+			fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic-v1", cfg.QUICPort),
+			fmt.Sprintf("/ip6/::/udp/%d/quic-v1", cfg.QUICPort),
+		)
+	}
+
+	return addrs
+}
+
+// advertisedAddrs maps the addresses we listen on to the ones we tell other
+// peers about, preferred IP family first. It is installed as the host's
+// address factory.
+func (n *Host) advertisedAddrs(addrs []ma.Multiaddr) []ma.Multiaddr {
+	return n.orderByIPFamily(n.reachableAddrs(addrs))
+}
+
+// reachableAddrs replaces listen addresses with the NAT-mapped or configured
+// external ones, keeping private addresses for local peers.
+func (n *Host) reachableAddrs(addrs []ma.Multiaddr) []ma.Multiaddr {
+	seen := make(map[string]struct{}, len(addrs))
+	result := make([]ma.Multiaddr, 0, len(addrs))
+	add := func(addr ma.Multiaddr) {
+		if _, ok := seen[addr.String()]; ok {
+			return
+		}
+		seen[addr.String()] = struct{}{}
+		result = append(result, addr)
+	}
+
+	if n.config().EnableNATPortMap {
+		if mapped := n.natMappedAddrs(addrs); len(mapped) > 0 {
+			for _, addr := range addrs {
+				if !manet.IsPublicAddr(addr) {
+					add(addr)
+				}
+			}
+			for _, addr := range mapped {
+				add(addr)
+			}
+			return result
+		}
+		// Mapping failed or hasn't completed yet, fall back to the
+		// configured external address.
+	}
+
+	for _, addr := range addrs {
+		if !manet.IsPublicAddr(addr) {
+			// Keep private addresses for peers on the same network.
+			add(addr)
+			if n.config().HostAddress == "" {
+				continue
+			}
+		}
+
+		external, err := n.externalAddr(addr)
+		if err != nil {
+			base.Log.Debug("Could not build external address", "addr", addr, "error", err)
+			continue
+		}
+		add(external)
+	}
+
+	return result
+}
+
+// orderByIPFamily moves addresses of the preferred IP family to the front,
+// keeping the relative order otherwise.
+func (n *Host) orderByIPFamily(addrs []ma.Multiaddr) []ma.Multiaddr {
+	var preferred int
+	switch n.config().IPFamilyPreference {
+	case config.PreferIPv4:
+		preferred = ma.P_IP4
+	case config.PreferIPv6:
+		preferred = ma.P_IP6
+	default:
+		return addrs
+	}
+
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return isIPFamily(addrs[i], preferred) && !isIPFamily(addrs[j], preferred)
+	})
+
+	return addrs
+}
+
+func isIPFamily(addr ma.Multiaddr, code int) bool {
+	protocols := addr.Protocols()
+	return len(protocols) > 0 && protocols[0].Code == code
+}
+
+// externalAddr rewrites the IP and port of a listen address to the configured
+// external host address and ports, leaving unset values untouched.
+func (n *Host) externalAddr(addr ma.Multiaddr) (ma.Multiaddr, error) {
+	cfg := n.config()
+	parts := strings.Split(addr.String(), "/")
+	for i := 1; i+1 < len(parts); i += 2 {
+		switch parts[i] {
+		case "ip4", "ip6":
+			if cfg.HostAddress == "" {
+				continue
+			}
+			ip := net.ParseIP(cfg.HostAddress)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid host address %q", cfg.HostAddress)
+			}
+			parts[i] = "ip6"
+			if ip.To4() != nil {
+				parts[i] = "ip4"
+			}
+			parts[i+1] = ip.String()
+		case "tcp":
+			if cfg.ExternalTCPPort > 0 {
+				parts[i+1] = strconv.Itoa(cfg.ExternalTCPPort)
+			}
+		case "udp":
+			if cfg.ExternalQUICPort > 0 {
+				parts[i+1] = strconv.Itoa(cfg.ExternalQUICPort)
+			}
+		}
+	}
+
+	return ma.NewMultiaddr(strings.Join(parts, "/"))
+}
+
+// natMapping looks up the external address a gateway port mapping gives a
+// local address. It is implemented by the libp2p NAT manager.
+type natMapping interface {
+	GetMapping(ma.Multiaddr) ma.Multiaddr
+}
+
+// newNATManager builds the libp2p NAT manager and keeps hold of it, so the
+// address factory can tell mapped addresses apart from other public ones.
+func (n *Host) newNATManager(nw network.Network) basichost.NATManager {
+	nat := basichost.NewNATManager(nw)
+
+	n.addrsLock.Lock()
+	n.nat = nat
+	n.addrsLock.Unlock()
+
+	return nat
+}
+
+// natMappedAddrs returns the public addresses the gateway mapped our private
+// addresses to, logging newly mapped ones.
+func (n *Host) natMappedAddrs(addrs []ma.Multiaddr) []ma.Multiaddr {
+	n.addrsLock.Lock()
+	defer n.addrsLock.Unlock()
+
+	if n.nat == nil {
+		return nil
+	}
+
+	var mapped []ma.Multiaddr
+	for _, addr := range addrs {
+		if manet.IsPublicAddr(addr) {
+			continue
+		}
+		if external := n.nat.GetMapping(addr); external != nil {
+			mapped = append(mapped, external)
+		}
+	}
+
+	current := make(map[string]struct{}, len(mapped))
+	for _, addr := range mapped {
+		current[addr.String()] = struct{}{}
+		if _, ok := n.natAddrs[addr.String()]; !ok {
+			base.Log.Info("NAT port mapping established", "addr", addr)
+		}
+	}
+	n.natAddrs = current
+
+	return mapped
+}
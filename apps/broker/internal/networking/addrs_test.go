@@ -0,0 +1,162 @@
+package networking
+
+import (
+	"net"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestAdvertisedAddrsUseExternalPorts(t *testing.T) {
+	n := newTestHost(t, &config.Config{
+		QUICPort:         freeUDPPort(t),
+		HostAddress:      "203.0.113.7",
+		ExternalTCPPort:  30303,
+		ExternalQUICPort: 30304,
+	})
+
+	want := map[string]bool{
+		"/ip4/203.0.113.7/tcp/30303":         false,
+		"/ip4/203.0.113.7/udp/30304/quic-v1": false,
+	}
+	for _, addr := range n.host.Addrs() {
+		if _, ok := want[addr.String()]; ok {
+			want[addr.String()] = true
+		}
+	}
+	for addr, found := range want {
+		if !found {
+			t.Errorf("expected %s to be advertised, got %v", addr, n.host.Addrs())
+		}
+	}
+}
+
+func TestExternalAddrKeepsPortsWhenUnset(t *testing.T) {
+	n := NewHost(&config.Config{HostAddress: "203.0.113.7"})
+
+	addr := ma.StringCast("/ip4/10.0.0.1/tcp/4001")
+	external, err := n.externalAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if external.String() != "/ip4/203.0.113.7/tcp/4001" {
+		t.Errorf("unexpected external address %s", external)
+	}
+}
+
+// fakeNAT maps local addresses to fixed external ones.
+type fakeNAT map[string]ma.Multiaddr
+
+func (f fakeNAT) GetMapping(addr ma.Multiaddr) ma.Multiaddr {
+	return f[addr.String()]
+}
+
+func TestAdvertisedAddrsPreferNATMapping(t *testing.T) {
+	// The addresses must be routable, manet.IsPublicAddr rejects the
+	// documentation ranges.
+	n := NewHost(&config.Config{
+		EnableNATPortMap: true,
+		HostAddress:      "93.184.216.34",
+		ExternalTCPPort:  30303,
+	})
+	private := ma.StringCast("/ip4/192.168.1.10/tcp/4001")
+	mapped := ma.StringCast("/ip4/185.199.108.1/tcp/51234")
+
+	// Without a mapping we fall back to the configured external address.
+	n.nat = fakeNAT{}
+	addrs := n.advertisedAddrs([]ma.Multiaddr{private})
+	if len(addrs) != 2 || addrs[1].String() != "/ip4/93.184.216.34/tcp/30303" {
+		t.Errorf("expected configured external address, got %v", addrs)
+	}
+
+	// Once the gateway maps the private address, the mapping is advertised.
+	n.nat = fakeNAT{private.String(): mapped}
+	addrs = n.advertisedAddrs([]ma.Multiaddr{private})
+	if len(addrs) != 2 || !addrs[1].Equal(mapped) {
+		t.Errorf("expected mapped address to be advertised, got %v", addrs)
+	}
+}
+
+func TestPublicAddrIsNotTakenForNATMapping(t *testing.T) {
+	n := NewHost(&config.Config{
+		EnableNATPortMap: true,
+		HostAddress:      "93.184.216.34",
+		ExternalTCPPort:  30303,
+	})
+	n.nat = fakeNAT{}
+	private := ma.StringCast("/ip4/192.168.1.10/tcp/4001")
+	observed := ma.StringCast("/ip4/185.199.108.1/tcp/4001")
+
+	// A public address that no mapping produced, e.g. one a peer observed,
+	// must not replace the configured external address.
+	addrs := n.advertisedAddrs([]ma.Multiaddr{private, observed})
+	for _, addr := range addrs {
+		if addr.Equal(observed) {
+			t.Errorf("expected unmapped public address not to be advertised, got %v", addrs)
+		}
+	}
+	if len(n.natAddrs) != 0 {
+		t.Errorf("expected no NAT mappings to be recorded, got %v", n.natAddrs)
+	}
+}
+
+func TestAdvertisedAddrsPreferIPFamily(t *testing.T) {
+	listen := []ma.Multiaddr{
+		ma.StringCast("/ip6/2001:db8::1/tcp/4001"),
+		ma.StringCast("/ip4/198.51.100.1/tcp/4001"),
+		ma.StringCast("/ip6/2001:db8::1/udp/4001/quic-v1"),
+		ma.StringCast("/ip4/198.51.100.1/udp/4001/quic-v1"),
+	}
+
+	for pref, want := range map[config.IPFamilyPreference]string{
+		config.PreferIPv4: "/ip4/198.51.100.1/tcp/4001",
+		config.PreferIPv6: "/ip6/2001:db8::1/tcp/4001",
+		config.PreferBoth: "/ip6/2001:db8::1/tcp/4001",
+	} {
+		n := NewHost(&config.Config{IPFamilyPreference: pref})
+		addrs := n.advertisedAddrs(append([]ma.Multiaddr(nil), listen...))
+		if len(addrs) != len(listen) {
+			t.Fatalf("%s: expected both families to be advertised, got %v", pref, addrs)
+		}
+		if addrs[0].String() != want {
+			t.Errorf("%s: expected %s first, got %v", pref, want, addrs)
+		}
+	}
+}
+
+func TestHostListensOnBothIPFamilies(t *testing.T) {
+	if l, err := net.Listen("tcp6", "[::1]:0"); err != nil {
+		t.Skip("IPv6 is not available:", err)
+	} else {
+		l.Close()
+	}
+
+	n := newTestHost(t, &config.Config{QUICPort: freeUDPPort(t)})
+
+	families := make(map[string]bool)
+	for _, addr := range n.host.Network().ListenAddresses() {
+		family := "ip4"
+		if isIPFamily(addr, ma.P_IP6) {
+			family = "ip6"
+		}
+		families[family+"/"+transportOf(addr)] = true
+	}
+	for _, want := range []string{"ip4/tcp", "ip6/tcp", "ip4/quic", "ip6/quic"} {
+		if !families[want] {
+			t.Errorf("expected a %s listener, got %v", want, n.host.Network().ListenAddresses())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package networking
+
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// asnFull reports whether connecting to addr would exceed MaxPeersPerASN.
+// Connected peers are attributed to the AS of their oldest connection; pid's
+// existing connections are not counted against itself.
+func (n *Host) asnFull(pid peer.ID, addr ma.Multiaddr) bool {
+	asn, ok := n.resolveASN(addr)
+	if !ok || n.config().MaxPeersPerASN <= 0 {
+		return false
+	}
+
+	count := 0
+	for _, connected := range n.host.Network().Peers() {
+		if connected == pid {
+			continue
+		}
+		conn := n.oldestConn(connected)
+		if conn == nil {
+			continue
+		}
+		if other, ok := n.resolveASN(conn.RemoteMultiaddr()); ok && other == asn {
+			count++
+		}
+	}
+
+	return count >= n.config().MaxPeersPerASN
+}
+
+func (n *Host) resolveASN(addr ma.Multiaddr) (uint32, bool) {
+	resolve := n.config().ASNResolver
+	if resolve == nil {
+		return 0, false
+	}
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return 0, false
+	}
+
+	return resolve(ip)
+}
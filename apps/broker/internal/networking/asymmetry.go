@@ -0,0 +1,114 @@
+package networking
+
+import (
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// maxPeerHistory bounds how many peers per-peer connection history is kept
+// for. Once it is reached, the peer last seen the longest ago is forgotten.
+const maxPeerHistory = 4096
+
+// connDirections remembers which directions we have ever been connected to a
+// peer in.
+type connDirections struct {
+	firstOutbound time.Time
+	lastSeen      time.Time
+	inbound       bool
+	logged        bool
+}
+
+// AsymmetricPeers returns peers we have only ever dialed and that never
+// dialed us, with the first outbound connection older than AsymmetryWindow.
+// Many such peers usually mean we are not reachable through our NAT.
+func (n *Host) AsymmetricPeers() []peer.ID {
+	if n.config().AsymmetryWindow <= 0 {
+		return nil
+	}
+
+	n.directionsLock.Lock()
+	defer n.directionsLock.Unlock()
+
+	var peers []peer.ID
+	for pid, dirs := range n.directions {
+		if n.isAsymmetric(dirs) {
+			peers = append(peers, pid)
+		}
+	}
+
+	return peers
+}
+
+// recordConnDirection notes the direction of a new connection. Peers that
+// turn out to be outbound-only are logged by watchAsymmetry.
+func (n *Host) recordConnDirection(pid peer.ID, dir network.Direction) {
+	if n.config().AsymmetryWindow <= 0 {
+		return
+	}
+
+	n.directionsLock.Lock()
+	defer n.directionsLock.Unlock()
+
+	dirs, ok := n.directions[pid]
+	if !ok {
+		if len(n.directions) >= maxPeerHistory {
+			n.evictDirections()
+		}
+		dirs = &connDirections{}
+		n.directions[pid] = dirs
+	}
+	dirs.lastSeen = time.Now()
+	switch dir {
+	case network.DirInbound:
+		dirs.inbound = true
+	case network.DirOutbound:
+		if dirs.firstOutbound.IsZero() {
+			dirs.firstOutbound = time.Now()
+		}
+	}
+}
+
+// watchAsymmetry periodically logs the peers that have been outbound-only
+// for longer than AsymmetryWindow, once each. It checks on a timer rather
+// than on new connections, so that a peer is reported even if we never
+// connect to anyone again.
+func (n *Host) watchAsymmetry() {
+	for n.waitInterval(func(cfg *config.Config) time.Duration { return sweepInterval(cfg.AsymmetryWindow) }) {
+		if n.config().AsymmetryWindow <= 0 {
+			continue
+		}
+
+		n.directionsLock.Lock()
+		for pid, dirs := range n.directions {
+			if !dirs.logged && n.isAsymmetric(dirs) {
+				dirs.logged = true
+				base.Log.Warn("Peer only ever connected outbound, we may not be reachable", "peer", pid,
+					"since", dirs.firstOutbound, "reachability", n.currentReachability())
+			}
+		}
+		n.directionsLock.Unlock()
+	}
+}
+
+// isAsymmetric reports whether dirs is outbound-only for longer than the
+// window. directionsLock must be held.
+func (n *Host) isAsymmetric(dirs *connDirections) bool {
+	return !dirs.inbound && !dirs.firstOutbound.IsZero() && time.Since(dirs.firstOutbound) >= n.config().AsymmetryWindow
+}
+
+// evictDirections forgets the peer we connected to the longest ago.
+// directionsLock must be held.
+func (n *Host) evictDirections() {
+	var stale peer.ID
+	var staleAt time.Time
+	for pid, dirs := range n.directions {
+		if staleAt.IsZero() || dirs.lastSeen.Before(staleAt) {
+			stale, staleAt = pid, dirs.lastSeen
+		}
+	}
+	delete(n.directions, stale)
+}
@@ -0,0 +1,72 @@
+package networking
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestAsymmetricPeers(t *testing.T) {
+	const window = 200 * time.Millisecond
+	client := newTestHost(t, &config.Config{AsymmetryWindow: window})
+	server := newTestHost(t, &config.Config{AsymmetryWindow: window})
+
+	connectHosts(t, client, server)
+	waitFor(t, func() bool { return server.ConnectedPeerCount() == 1 })
+	if len(client.AsymmetricPeers()) != 0 {
+		t.Fatal("expected no asymmetric peers before the window passes")
+	}
+
+	time.Sleep(window)
+	peers := client.AsymmetricPeers()
+	if len(peers) != 1 || peers[0] != server.host.ID() {
+		t.Errorf("expected the outbound-only peer to be flagged, got %v", peers)
+	}
+	if len(server.AsymmetricPeers()) != 0 {
+		t.Error("expected inbound-only peers not to be flagged")
+	}
+}
+
+func TestAsymmetricPeerLoggedWithoutNewConnections(t *testing.T) {
+	const window = 200 * time.Millisecond
+	client := newTestHost(t, &config.Config{AsymmetryWindow: window})
+	server := newTestHost(t, &config.Config{})
+	connectHosts(t, client, server)
+
+	// No connection is made after the window passes, yet the peer is
+	// reported.
+	waitFor(t, func() bool {
+		client.directionsLock.Lock()
+		defer client.directionsLock.Unlock()
+		dirs, ok := client.directions[server.host.ID()]
+		return ok && dirs.logged
+	})
+}
+
+func TestConnDirectionsAreBounded(t *testing.T) {
+	n := NewHost(&config.Config{AsymmetryWindow: time.Hour})
+
+	for i := range maxPeerHistory {
+		n.recordConnDirection(peer.ID(fmt.Sprintf("peer-%d", i)), network.DirOutbound)
+	}
+	n.directionsLock.Lock()
+	n.directions[peer.ID("peer-0")].lastSeen = time.Now().Add(-time.Hour)
+	n.directionsLock.Unlock()
+	n.recordConnDirection(peer.ID("new"), network.DirOutbound)
+
+	n.directionsLock.Lock()
+	defer n.directionsLock.Unlock()
+	if len(n.directions) != maxPeerHistory {
+		t.Errorf("expected directions for %d peers, got %d", maxPeerHistory, len(n.directions))
+	}
+	if _, ok := n.directions[peer.ID("peer-0")]; ok {
+		t.Error("expected the peer seen the longest ago to be forgotten")
+	}
+	if _, ok := n.directions[peer.ID("new")]; !ok {
+		t.Error("expected the new peer to be tracked")
+	}
+}
@@ -0,0 +1,166 @@
+package networking
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// blocklist holds banned peers and address ranges loaded from a file.
+type blocklist struct {
+	peers   map[peer.ID]struct{}
+	filters *ma.Filters
+}
+
+// loadBlocklist parses a blocklist file. Each line holds a peer ID, an IP or
+// a CIDR; empty lines and lines starting with # are ignored. Malformed lines
+// are logged and skipped.
+func loadBlocklist(path string) (*blocklist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &blocklist{
+		peers:   make(map[peer.ID]struct{}),
+		filters: ma.NewFilters(),
+	}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if ipNet := parseIPNet(entry); ipNet != nil {
+			list.filters.AddFilter(*ipNet, ma.ActionDeny)
+			continue
+		}
+		if pid, err := peer.Decode(entry); err == nil {
+			list.peers[pid] = struct{}{}
+			continue
+		}
+
+		base.Log.Warn("Skipping malformed blocklist entry", "path", path, "line", line, "entry", entry)
+	}
+
+	return list, scanner.Err()
+}
+
+func parseIPNet(entry string) *net.IPNet {
+	if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+		return ipNet
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+// reloadBlocklist replaces the active blocklist with the configured file, or
+// clears it when no file is configured.
+func (n *Host) reloadBlocklist() {
+	cfg := n.config()
+	if cfg.BlocklistPath == "" {
+		n.blocklistLock.Lock()
+		n.blocklist = nil
+		n.blocklistLock.Unlock()
+		return
+	}
+
+	list, err := loadBlocklist(cfg.BlocklistPath)
+	if err != nil {
+		base.Log.Error("Failed to load blocklist", "path", cfg.BlocklistPath, "error", err)
+		return
+	}
+
+	n.blocklistLock.Lock()
+	n.blocklist = list
+	n.blocklistLock.Unlock()
+
+	base.Log.Info("Loaded blocklist", "path", cfg.BlocklistPath, "peers", len(list.peers))
+	n.disconnectBlocked()
+}
+
+// disconnectBlocked closes the connections the current blocklist forbids:
+// all connections of banned peers, and those to banned addresses. The gater
+// only sees new connections, so without this existing ones would stay open.
+func (n *Host) disconnectBlocked() {
+	if n.host == nil {
+		return
+	}
+
+	for _, pid := range n.host.Network().Peers() {
+		if n.isBlockedPeer(pid) {
+			base.Log.Info("Disconnecting blocklisted peer", "peer", pid)
+			if err := n.host.Network().ClosePeer(pid); err != nil {
+				base.Log.Debug("Failed to disconnect blocklisted peer", "peer", pid, "error", err)
+			}
+			continue
+		}
+		for _, conn := range n.host.Network().ConnsToPeer(pid) {
+			if n.isBlockedAddr(conn.RemoteMultiaddr()) && !n.isAllowedAddr(conn.RemoteMultiaddr()) {
+				base.Log.Info("Closing connection to blocklisted address", "peer", pid, "addr", conn.RemoteMultiaddr())
+				_ = conn.Close()
+			}
+		}
+	}
+}
+
+// watchBlocklist reloads the blocklist whenever the process receives SIGHUP.
+// Only the first call starts a watcher; it always reloads the currently
+// configured file.
+func (n *Host) watchBlocklist() {
+	n.blocklistWatch.Do(func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGHUP)
+
+		go func() {
+			defer signal.Stop(sigCh)
+			for {
+				select {
+				case <-sigCh:
+					n.reloadBlocklist()
+				case <-n.ctx.Done():
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (n *Host) isBlockedPeer(pid peer.ID) bool {
+	n.blocklistLock.RLock()
+	defer n.blocklistLock.RUnlock()
+
+	if n.blocklist == nil {
+		return false
+	}
+	_, ok := n.blocklist.peers[pid]
+
+	return ok
+}
+
+func (n *Host) isBlockedAddr(addr ma.Multiaddr) bool {
+	n.blocklistLock.RLock()
+	defer n.blocklistLock.RUnlock()
+
+	return n.blocklist != nil && n.blocklist.filters.AddrBlocked(addr)
+}
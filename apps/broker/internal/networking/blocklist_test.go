@@ -0,0 +1,94 @@
+package networking
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestBlocklist(t *testing.T) {
+	banned := newTestHost(t, &config.Config{})
+	allowed := newTestHost(t, &config.Config{})
+
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	content := "# shared abuse list\n" +
+		banned.host.ID().String() + "\n" +
+		"198.51.100.0/24\n" +
+		"203.0.113.9\n" +
+		"not-a-peer-or-cidr\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newTestHost(t, &config.Config{BlocklistPath: path})
+
+	expectRejected(t, banned, server)
+	connectHosts(t, allowed, server)
+
+	g := &gater{n: server}
+	if g.InterceptAddrDial(allowed.host.ID(), ma.StringCast("/ip4/198.51.100.20/tcp/4001")) {
+		t.Error("expected address in blocklisted CIDR to be rejected")
+	}
+	if g.InterceptAddrDial(allowed.host.ID(), ma.StringCast("/ip4/203.0.113.9/tcp/4001")) {
+		t.Error("expected blocklisted IP to be rejected")
+	}
+	if !g.InterceptAddrDial(allowed.host.ID(), ma.StringCast("/ip4/203.0.113.10/tcp/4001")) {
+		t.Error("expected address outside the blocklist to be allowed")
+	}
+}
+
+func TestBlocklistReloadDisconnectsConnectedPeer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	server := newTestHost(t, &config.Config{BlocklistPath: path})
+	banned := newTestHost(t, &config.Config{})
+	allowed := newTestHost(t, &config.Config{})
+	connectHosts(t, banned, server)
+	connectHosts(t, allowed, server)
+	waitFor(t, func() bool { return server.ConnectedPeerCount() == 2 })
+
+	if err := os.WriteFile(path, []byte(banned.host.ID().String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		return server.host.Network().Connectedness(banned.host.ID()) != network.Connected
+	})
+	if server.host.Network().Connectedness(allowed.host.ID()) != network.Connected {
+		t.Error("expected peers not on the blocklist to stay connected")
+	}
+}
+
+func TestReloadConfigKeepsOneBlocklistWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	n := NewHost(&config.Config{BlocklistPath: path})
+	defer n.cancel()
+
+	// Clearing and setting the path again must not start a second watcher.
+	for _, p := range []string{"", path} {
+		cfg := *n.config()
+		cfg.BlocklistPath = p
+		if err := n.ReloadConfig(&cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	started := false
+	n.blocklistWatch.Do(func() { started = true })
+	if started {
+		t.Error("expected the blocklist watcher to be running already")
+	}
+}
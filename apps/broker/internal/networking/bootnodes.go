@@ -0,0 +1,105 @@
+package networking
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// bootnodeDialTimeout bounds a single bootnode dial.
+	bootnodeDialTimeout = 10 * time.Second
+	// bootnodeTag protects connected bootnodes in the connection manager,
+	// like staticPeerTag.
+	bootnodeTag = "bootnode"
+)
+
+// BootnodeResult is the outcome of the latest dial to a configured bootnode.
+// Error is set when the address could not be parsed or the dial failed.
+type BootnodeResult struct {
+	Addr      string
+	Peer      peer.ID
+	Connected bool
+	Latency   time.Duration
+	Error     string
+}
+
+// BootnodeStatus returns the result of the most recent bootnode dials, in
+// the order the bootnodes are configured. It is empty until the first round
+// of dials finishes.
+func (n *Host) BootnodeStatus() []BootnodeResult {
+	n.bootnodesLock.RLock()
+	defer n.bootnodesLock.RUnlock()
+
+	return append([]BootnodeResult(nil), n.bootnodeResults...)
+}
+
+// BootnodeFailureStats returns how many dials to each bootnode, keyed by its
+// configured address, have failed since the host started or the stats were
+// last reset. Bootnodes that never failed are left out.
+func (n *Host) BootnodeFailureStats() map[string]int {
+	n.bootnodesLock.RLock()
+	defer n.bootnodesLock.RUnlock()
+
+	return maps.Clone(n.bootnodeFailures)
+}
+
+// ResetBootnodeFailureStats clears the counts reported by
+// BootnodeFailureStats.
+func (n *Host) ResetBootnodeFailureStats() {
+	n.bootnodesLock.Lock()
+	defer n.bootnodesLock.Unlock()
+
+	clear(n.bootnodeFailures)
+}
+
+// connectToBootnodes dials all configured bootnodes concurrently and waits
+// for the dials to finish. Bootnodes that are still configured once
+// connected are protected.
+func (n *Host) connectToBootnodes() {
+	bootnodes := n.config().Bootnodes
+	results := make([]BootnodeResult, len(bootnodes))
+
+	var wg sync.WaitGroup
+	for i, addr := range bootnodes {
+		results[i].Addr = addr
+		info, err := peer.AddrInfoFromString(addr)
+		if err != nil {
+			base.Log.Error("Invalid bootnode address", "addr", addr, "error", err)
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Peer = info.ID
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(n.ctx, bootnodeDialTimeout)
+			defer cancel()
+			start := time.Now()
+			if err := n.connectWithPeer(ctx, *info); err != nil {
+				results[i].Error = err.Error()
+				n.bootnodesLock.Lock()
+				n.bootnodeFailures[addr]++
+				n.bootnodesLock.Unlock()
+				return
+			}
+			results[i].Connected = true
+			results[i].Latency = time.Since(start)
+			if slices.Contains(n.config().Bootnodes, addr) {
+				n.host.ConnManager().Protect(info.ID, bootnodeTag)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n.bootnodesLock.Lock()
+	n.bootnodeResults = results
+	n.bootnodesLock.Unlock()
+}
@@ -0,0 +1,69 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestBootnodeStatus(t *testing.T) {
+	reachable := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: reachable.host.ID(), Addrs: reachable.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unreachable := unreachablePeer(t)
+	dead, err := peer.AddrInfoToP2pAddrs(&unreachable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := newTestHost(t, &config.Config{
+		Bootnodes: []string{addrs[0].String(), dead[0].String(), "not-a-multiaddr"},
+	})
+	waitFor(t, func() bool { return len(n.BootnodeStatus()) == 3 })
+
+	status := n.BootnodeStatus()
+	if !status[0].Connected || status[0].Latency <= 0 || status[0].Peer != reachable.host.ID() {
+		t.Errorf("expected the reachable bootnode to connect, got %+v", status[0])
+	}
+	if status[1].Connected || status[1].Error == "" || status[1].Peer != unreachable.ID {
+		t.Errorf("expected the unreachable bootnode to fail, got %+v", status[1])
+	}
+	if status[2].Connected || status[2].Error == "" || status[2].Peer != "" {
+		t.Errorf("expected a parse error for the malformed bootnode, got %+v", status[2])
+	}
+}
+
+func TestBootnodeFailureStats(t *testing.T) {
+	reachable := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: reachable.host.ID(), Addrs: reachable.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable := unreachablePeer(t)
+	dead, err := peer.AddrInfoToP2pAddrs(&unreachable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Init dials the bootnodes once in the background; wait for that round
+	// before adding two more so that the count is exact.
+	n := newTestHost(t, &config.Config{Bootnodes: []string{addrs[0].String(), dead[0].String()}})
+	waitFor(t, func() bool { return n.BootnodeFailureStats()[dead[0].String()] == 1 })
+	for range 2 {
+		n.connectToBootnodes()
+	}
+
+	stats := n.BootnodeFailureStats()
+	if len(stats) != 1 || stats[dead[0].String()] != 3 {
+		t.Errorf("expected three failures for the unreachable bootnode only, got %v", stats)
+	}
+
+	n.ResetBootnodeFailureStats()
+	if stats := n.BootnodeFailureStats(); len(stats) != 0 {
+		t.Errorf("expected no failures after a reset, got %v", stats)
+	}
+}
@@ -0,0 +1,132 @@
+package networking
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+const (
+	// chunkTimeout bounds how long reading or writing a single chunk may take,
+	// unless ProtocolDeadlines says otherwise.
+	chunkTimeout = 10 * time.Second
+	// maxChunkSize bounds the encoded size of a single chunk.
+	maxChunkSize = 10 << 20
+
+	chunkCodeSuccess byte = 0
+	chunkCodeError   byte = 1
+)
+
+var (
+	ErrChunkTooLarge  = errors.New("chunk exceeds maximum size")
+	ErrMalformedChunk = errors.New("malformed chunk")
+)
+
+// ChunkError is returned by ReadChunks when the remote ends the stream with an
+// error chunk.
+type ChunkError struct {
+	Message string
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("remote error: %s", e.Message)
+}
+
+// WriteChunks writes each item as a length-prefixed chunk. Every chunk starts
+// with a result code, followed by the uvarint length and the encoded item.
+func WriteChunks(stream network.Stream, chunks []encoding.BinaryMarshaler) error {
+	for i, chunk := range chunks {
+		data, err := chunk.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to encode chunk %d: %w", i, err)
+		}
+		if err := writeChunk(stream, chunkCodeSuccess, data); err != nil {
+			return fmt.Errorf("failed to write chunk %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// WriteErrorChunk ends a chunked response with an error, e.g. when the
+// responder fails halfway through.
+func WriteErrorChunk(stream network.Stream, msg string) error {
+	return writeChunk(stream, chunkCodeError, []byte(msg))
+}
+
+// ReadChunks reads chunks until the remote closes the stream, decoding each
+// into a fresh item and passing it to onItem. It stops at the first error.
+func ReadChunks(stream network.Stream, newItem func() encoding.BinaryUnmarshaler, onItem func(encoding.BinaryUnmarshaler) error) error {
+	timeout, _ := chunkDeadlines(stream)
+	r := bufio.NewReader(stream)
+	for i := 0; ; i++ {
+		if err := stream.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+
+		code, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %d: %w", i, err)
+		}
+
+		data, err := readChunkData(r)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %d: %w", i, err)
+		}
+		if code != chunkCodeSuccess {
+			return &ChunkError{Message: string(data)}
+		}
+
+		item := newItem()
+		if err := item.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("%w %d: %w", ErrMalformedChunk, i, err)
+		}
+		if err := onItem(item); err != nil {
+			return err
+		}
+	}
+}
+
+func writeChunk(stream network.Stream, code byte, data []byte) error {
+	if len(data) > maxChunkSize {
+		return ErrChunkTooLarge
+	}
+	_, timeout := chunkDeadlines(stream)
+	if err := stream.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(data))
+	buf[0] = code
+	n := binary.PutUvarint(buf[1:], uint64(len(data)))
+	buf = append(buf[:1+n], data...)
+
+	_, err := stream.Write(buf)
+	return err
+}
+
+func readChunkData(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxChunkSize {
+		return nil, ErrChunkTooLarge
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,102 @@
+package networking
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+const testChunksProtocol = "/test/chunks/1.0.0"
+
+type testItem struct {
+	value string
+}
+
+func (i *testItem) MarshalBinary() ([]byte, error) {
+	return []byte(i.value), nil
+}
+
+func (i *testItem) UnmarshalBinary(data []byte) error {
+	i.value = string(data)
+	return nil
+}
+
+func readTestChunks(t *testing.T, handler network.StreamHandler) ([]string, error) {
+	t.Helper()
+
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+	server.host.SetStreamHandler(testChunksProtocol, handler)
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.host.NewStream(ctx, server.host.ID(), testChunksProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var values []string
+	err = ReadChunks(stream,
+		func() encoding.BinaryUnmarshaler { return &testItem{} },
+		func(item encoding.BinaryUnmarshaler) error {
+			values = append(values, item.(*testItem).value)
+			return nil
+		},
+	)
+
+	return values, err
+}
+
+func TestChunksRoundTrip(t *testing.T) {
+	values, err := readTestChunks(t, func(s network.Stream) {
+		defer s.Close()
+		chunks := []encoding.BinaryMarshaler{&testItem{"a"}, &testItem{"bb"}, &testItem{"ccc"}}
+		if err := WriteChunks(s, chunks); err != nil {
+			t.Error(err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || values[0] != "a" || values[1] != "bb" || values[2] != "ccc" {
+		t.Errorf("unexpected chunks %v", values)
+	}
+}
+
+func TestChunksMidStreamError(t *testing.T) {
+	values, err := readTestChunks(t, func(s network.Stream) {
+		defer s.Close()
+		if err := WriteChunks(s, []encoding.BinaryMarshaler{&testItem{"a"}}); err != nil {
+			t.Error(err)
+		}
+		if err := WriteErrorChunk(s, "range unavailable"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var chunkErr *ChunkError
+	if !errors.As(err, &chunkErr) || chunkErr.Message != "range unavailable" {
+		t.Fatalf("expected remote chunk error, got %v", err)
+	}
+	if len(values) != 1 {
+		t.Errorf("expected the chunk before the error to be delivered, got %v", values)
+	}
+}
+
+func TestChunksTruncatedStream(t *testing.T) {
+	_, err := readTestChunks(t, func(s network.Stream) {
+		defer s.Close()
+		// Announce a 10 byte chunk but only send 3.
+		_, _ = s.Write([]byte{chunkCodeSuccess, 10, 'a', 'b', 'c'})
+	})
+	if err == nil {
+		t.Fatal("expected truncated chunk to fail")
+	}
+}
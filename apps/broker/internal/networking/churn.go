@@ -0,0 +1,31 @@
+package networking
+
+// ChurnSnapshot counts peers that connected and disconnected over an
+// interval.
+type ChurnSnapshot struct {
+	Connects    int
+	Disconnects int
+}
+
+// SnapshotAndResetChurn returns the peer connects and disconnects since the
+// previous call and resets both counters.
+func (n *Host) SnapshotAndResetChurn() ChurnSnapshot {
+	n.churnLock.Lock()
+	defer n.churnLock.Unlock()
+
+	snapshot := n.churn
+	n.churn = ChurnSnapshot{}
+
+	return snapshot
+}
+
+func (n *Host) recordChurn(connected bool) {
+	n.churnLock.Lock()
+	defer n.churnLock.Unlock()
+
+	if connected {
+		n.churn.Connects++
+	} else {
+		n.churn.Disconnects++
+	}
+}
@@ -0,0 +1,33 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+func TestSnapshotAndResetChurn(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+
+	connectHosts(t, n, a)
+	connectHosts(t, n, b)
+	_ = n.host.Network().ClosePeer(a.host.ID())
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+
+	var total ChurnSnapshot
+	waitFor(t, func() bool {
+		s := n.SnapshotAndResetChurn()
+		total.Connects += s.Connects
+		total.Disconnects += s.Disconnects
+		return total.Disconnects == 1
+	})
+	if total.Connects != 2 {
+		t.Errorf("expected 2 connects, got %d", total.Connects)
+	}
+
+	if s := n.SnapshotAndResetChurn(); s != (ChurnSnapshot{}) {
+		t.Errorf("expected counters to reset after a snapshot, got %+v", s)
+	}
+}
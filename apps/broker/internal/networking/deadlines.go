@@ -0,0 +1,41 @@
+package networking
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// deadlineStream carries the chunk deadlines configured for its protocol.
+type deadlineStream struct {
+	network.Stream
+	read  time.Duration
+	write time.Duration
+}
+
+// withDeadlines wraps s with the deadlines configured for protocolID, if any.
+func (n *Host) withDeadlines(s network.Stream, protocolID protocol.ID) network.Stream {
+	d, ok := n.config().ProtocolDeadlines[string(protocolID)]
+	if !ok {
+		return s
+	}
+
+	return &deadlineStream{Stream: s, read: d.Read, write: d.Write}
+}
+
+// chunkDeadlines returns how long reading and writing a single chunk on
+// stream may take, falling back to chunkTimeout.
+func chunkDeadlines(stream network.Stream) (read, write time.Duration) {
+	read, write = chunkTimeout, chunkTimeout
+	if s, ok := stream.(*deadlineStream); ok {
+		if s.read > 0 {
+			read = s.read
+		}
+		if s.write > 0 {
+			write = s.write
+		}
+	}
+
+	return read, write
+}
@@ -0,0 +1,84 @@
+package networking
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+const (
+	testSlowProtocol  = "/test/slow/1.0.0"
+	testQuickProtocol = "/test/quick/1.0.0"
+)
+
+// slowHandler answers each request with the request itself after delay,
+// ignoring requesters that gave up in the meantime.
+func slowHandler(delay time.Duration) network.StreamHandler {
+	return func(s network.Stream) {
+		defer s.Close()
+
+		var req testItem
+		err := ReadChunks(s,
+			func() encoding.BinaryUnmarshaler { return &req },
+			func(encoding.BinaryUnmarshaler) error { return nil },
+		)
+		if err != nil {
+			return
+		}
+		time.Sleep(delay)
+		_ = WriteChunks(s, []encoding.BinaryMarshaler{&req})
+	}
+}
+
+func TestProtocolDeadlines(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{
+		ProtocolDeadlines: map[string]config.ProtocolDeadline{
+			testSlowProtocol:  {Read: 5 * time.Second},
+			testQuickProtocol: {Read: 50 * time.Millisecond},
+		},
+	})
+	server.setStreamHandler(testSlowProtocol, slowHandler(200*time.Millisecond))
+	server.setStreamHandler(testQuickProtocol, slowHandler(200*time.Millisecond))
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp testItem
+	if err := client.Request(ctx, server.host.ID(), testSlowProtocol, &testItem{"slow"}, &resp); err != nil {
+		t.Fatalf("expected the longer deadline to cover the slow response, got %v", err)
+	}
+	if resp.value != "slow" {
+		t.Errorf("unexpected response %q", resp.value)
+	}
+
+	err := client.Request(ctx, server.host.ID(), testQuickProtocol, &testItem{"quick"}, &resp)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected the short deadline to expire, got %v", err)
+	}
+}
+
+func TestChunkDeadlinesFallBack(t *testing.T) {
+	n := NewHost(&config.Config{
+		ProtocolDeadlines: map[string]config.ProtocolDeadline{
+			testSlowProtocol: {Write: time.Minute},
+		},
+	})
+
+	read, write := chunkDeadlines(n.withDeadlines(nil, testSlowProtocol))
+	if read != chunkTimeout || write != time.Minute {
+		t.Errorf("expected the default read and custom write deadline, got %v and %v", read, write)
+	}
+	read, write = chunkDeadlines(n.withDeadlines(nil, testQuickProtocol))
+	if read != chunkTimeout || write != chunkTimeout {
+		t.Errorf("expected the default deadlines, got %v and %v", read, write)
+	}
+}
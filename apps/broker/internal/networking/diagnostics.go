@@ -0,0 +1,132 @@
+package networking
+
+import (
+	"encoding/json"
+	"time"
+
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// HostConfig is the effective configuration of the running libp2p host.
+type HostConfig struct {
+	PeerID          string            `json:"peer_id"`
+	ListenAddrs     []string          `json:"listen_addrs"`
+	AdvertisedAddrs []string          `json:"advertised_addrs"`
+	Transports      []string          `json:"transports"`
+	Security        []string          `json:"security"`
+	NATPortMap      bool              `json:"nat_port_map"`
+	ConnManager     ConnManagerConfig `json:"conn_manager"`
+	ResourceLimits  ResourceLimits    `json:"resource_limits"`
+}
+
+// ConnManagerConfig holds the connection manager watermarks.
+type ConnManagerConfig struct {
+	LowWater    int           `json:"low_water"`
+	HighWater   int           `json:"high_water"`
+	GracePeriod time.Duration `json:"grace_period"`
+}
+
+// ResourceLimits holds the system-wide limits of the resource manager.
+type ResourceLimits struct {
+	Conns         Limit `json:"conns"`
+	ConnsInbound  Limit `json:"conns_inbound"`
+	ConnsOutbound Limit `json:"conns_outbound"`
+	Streams       Limit `json:"streams"`
+	FD            Limit `json:"fd"`
+	Memory        Limit `json:"memory"`
+}
+
+// Limit is a resource manager limit. Besides a number it can be one of the
+// resource manager's sentinels, which marshal as "unlimited" and "blocked".
+type Limit int64
+
+const (
+	LimitUnlimited = Limit(rcmgr.Unlimited)
+	LimitBlocked   = Limit(rcmgr.BlockAllLimit)
+)
+
+func (l Limit) MarshalJSON() ([]byte, error) {
+	switch l {
+	case LimitUnlimited:
+		return json.Marshal("unlimited")
+	case LimitBlocked:
+		return json.Marshal("blocked")
+	}
+
+	return json.Marshal(int64(l))
+}
+
+// HostConfigSnapshot returns the effective host configuration, including
+// values filled in by libp2p defaults. It must be called after Init.
+func (n *Host) HostConfigSnapshot() HostConfig {
+	listen := n.host.Network().ListenAddresses()
+
+	return HostConfig{
+		PeerID:          n.host.ID().String(),
+		ListenAddrs:     addrStrings(listen),
+		AdvertisedAddrs: addrStrings(n.host.Addrs()),
+		Transports:      transportsOf(listen),
+		Security:        securityIDs(),
+		NATPortMap:      n.config().EnableNATPortMap,
+		ConnManager: ConnManagerConfig{
+			LowWater:    connMgrLowWater,
+			HighWater:   connMgrHighWater,
+			GracePeriod: connMgrGracePeriod,
+		},
+		ResourceLimits: resourceLimitsOf(n.limits.ToPartialLimitConfig().System),
+	}
+}
+
+// resourceLimitsOf converts partial limits, in which unlimited and blocked
+// resources are sentinels rather than numbers.
+func resourceLimitsOf(system rcmgr.ResourceLimits) ResourceLimits {
+	return ResourceLimits{
+		Conns:         Limit(system.Conns),
+		ConnsInbound:  Limit(system.ConnsInbound),
+		ConnsOutbound: Limit(system.ConnsOutbound),
+		Streams:       Limit(system.Streams),
+		FD:            Limit(system.FD),
+		Memory:        Limit(system.Memory),
+	}
+}
+
+func addrStrings(addrs []ma.Multiaddr) []string {
+	result := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		result = append(result, addr.String())
+	}
+
+	return result
+}
+
+// transportOf names the transport of an address, e.g. "tcp" or "quic".
+// Circuit addresses are named "relay" whichever transport reaches the relay.
+func transportOf(addr ma.Multiaddr) string {
+	if _, err := addr.ValueForProtocol(ma.P_CIRCUIT); err == nil {
+		return "relay"
+	}
+	if _, err := addr.ValueForProtocol(ma.P_QUIC_V1); err == nil {
+		return "quic"
+	}
+	if _, err := addr.ValueForProtocol(ma.P_TCP); err == nil {
+		return "tcp"
+	}
+
+	return "unknown"
+}
+
+func transportsOf(addrs []ma.Multiaddr) []string {
+	seen := make(map[string]struct{})
+	var transports []string
+	for _, addr := range addrs {
+		t := transportOf(addr)
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		transports = append(transports, t)
+	}
+
+	return transports
+}
@@ -0,0 +1,68 @@
+package networking
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+func TestHostConfigSnapshot(t *testing.T) {
+	n := newTestHost(t, &config.Config{QUICPort: freeUDPPort(t)})
+
+	snapshot := n.HostConfigSnapshot()
+	if snapshot.PeerID != n.host.ID().String() {
+		t.Errorf("unexpected peer ID %s", snapshot.PeerID)
+	}
+	if len(snapshot.ListenAddrs) == 0 || len(snapshot.AdvertisedAddrs) == 0 {
+		t.Errorf("expected listen and advertised addresses, got %+v", snapshot)
+	}
+	// The relay transport also listens, on /p2p-circuit.
+	for _, want := range []string{"tcp", "quic", "relay"} {
+		if !slices.Contains(snapshot.Transports, want) {
+			t.Errorf("expected a %s transport, got %v", want, snapshot.Transports)
+		}
+	}
+	if slices.Contains(snapshot.Transports, "unknown") {
+		t.Errorf("expected every transport to be named, got %v", snapshot.Transports)
+	}
+	if snapshot.ConnManager.HighWater != connMgrHighWater {
+		t.Errorf("unexpected conn manager config %+v", snapshot.ConnManager)
+	}
+	if !slices.Equal(snapshot.Security, []string{"/tls/1.0.0", "/noise"}) {
+		t.Errorf("expected the configured security protocols, got %v", snapshot.Security)
+	}
+	if snapshot.ResourceLimits.Conns == 0 {
+		t.Errorf("expected resource limits to be populated, got %+v", snapshot.ResourceLimits)
+	}
+
+	if _, err := json.Marshal(snapshot); err != nil {
+		t.Errorf("snapshot is not serializable: %v", err)
+	}
+}
+
+func TestResourceLimitSentinels(t *testing.T) {
+	limits := resourceLimitsOf(rcmgr.ResourceLimits{
+		Conns:   rcmgr.Unlimited,
+		Streams: rcmgr.BlockAllLimit,
+		FD:      512,
+		Memory:  rcmgr.Unlimited64,
+	})
+
+	data, err := json.Marshal(limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"conns": "unlimited", "streams": "blocked", "fd": 512.0, "memory": "unlimited"}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, got[key])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package networking
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// inFlightDial is a dial that hasn't completed yet.
+type inFlightDial struct {
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+// connectWithPeer dials a peer, tracking the dial so it can be cancelled. A
+// failure is logged here at dialLogLevel, so callers must not log it again.
+// Concurrent dials to the same peer are tracked side by side.
+func (n *Host) connectWithPeer(ctx context.Context, info peer.AddrInfo) error {
+	ctx, cancel := context.WithCancel(ctx)
+	dial := &inFlightDial{started: time.Now(), cancel: cancel}
+
+	n.dialsLock.Lock()
+	n.dials[info.ID] = append(n.dials[info.ID], dial)
+	n.dialsLock.Unlock()
+
+	defer func() {
+		n.dialsLock.Lock()
+		dials := slices.DeleteFunc(n.dials[info.ID], func(d *inFlightDial) bool { return d == dial })
+		if len(dials) == 0 {
+			delete(n.dials, info.ID)
+		} else {
+			n.dials[info.ID] = dials
+		}
+		n.dialsLock.Unlock()
+		cancel()
+	}()
+
+	if err := n.host.Connect(ctx, info); err != nil {
+		base.Log.Log(context.Background(), n.dialLogLevel(), "Failed to connect to peer", "peer", info.ID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// InFlightDials returns the peers we are currently dialing and for how long,
+// counting from the oldest dial when there are several.
+func (n *Host) InFlightDials() map[peer.ID]time.Duration {
+	n.dialsLock.Lock()
+	defer n.dialsLock.Unlock()
+
+	now := time.Now()
+	dials := make(map[peer.ID]time.Duration, len(n.dials))
+	for pid, inFlight := range n.dials {
+		dials[pid] = now.Sub(inFlight[0].started)
+	}
+
+	return dials
+}
+
+// CancelDial aborts all in-flight dials to the given peer. It reports whether
+// a dial was cancelled.
+func (n *Host) CancelDial(pid peer.ID) bool {
+	n.dialsLock.Lock()
+	defer n.dialsLock.Unlock()
+
+	inFlight, ok := n.dials[pid]
+	if !ok {
+		return false
+	}
+	for _, dial := range inFlight {
+		dial.cancel()
+	}
+	delete(n.dials, pid)
+
+	return true
+}
@@ -0,0 +1,148 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// familyDialDelay is how long after the last dial of an address family the
+// next, less successful family is dialed.
+const familyDialDelay = 250 * time.Millisecond
+
+// AddressFamilyStat counts dial outcomes for an address family.
+type AddressFamilyStat struct {
+	Successes int
+	Failures  int
+}
+
+// successRate returns the fraction of successful dials, treating families we
+// have no history for as neutral.
+func (s AddressFamilyStat) successRate() float64 {
+	total := s.Successes + s.Failures
+	if total == 0 {
+		return 0.5
+	}
+
+	return float64(s.Successes) / float64(total)
+}
+
+// AddressFamilyStats returns the dial outcomes per address family.
+func (n *Host) AddressFamilyStats() map[string]AddressFamilyStat {
+	n.familiesLock.Lock()
+	defer n.familiesLock.Unlock()
+
+	stats := make(map[string]AddressFamilyStat, len(n.families))
+	for family, stat := range n.families {
+		stats[family] = stat
+	}
+
+	return stats
+}
+
+// rankDialAddrs dials address families in order of their historical success
+// rate, so that families which usually work are tried first. Within a family
+// the default ranking applies.
+func (n *Host) rankDialAddrs(addrs []ma.Multiaddr) []network.AddrDelay {
+	stats := n.AddressFamilyStats()
+
+	var families []string
+	byFamily := make(map[string][]ma.Multiaddr)
+	for _, addr := range addrs {
+		family := addrFamily(addr)
+		if _, ok := byFamily[family]; !ok {
+			families = append(families, family)
+		}
+		byFamily[family] = append(byFamily[family], addr)
+	}
+
+	sort.SliceStable(families, func(i, j int) bool {
+		return stats[families[i]].successRate() > stats[families[j]].successRate()
+	})
+	if len(families) < 2 || stats[families[0]].successRate() == stats[families[len(families)-1]].successRate() {
+		// Nothing learned that sets the families apart.
+		return swarm.DefaultDialRanker(addrs)
+	}
+
+	ranked := make([]network.AddrDelay, 0, len(addrs))
+	var offset time.Duration
+	for _, family := range families {
+		var last time.Duration
+		for _, ad := range swarm.DefaultDialRanker(byFamily[family]) {
+			ad.Delay += offset
+			if ad.Delay > last {
+				last = ad.Delay
+			}
+			ranked = append(ranked, ad)
+		}
+		offset = last + familyDialDelay
+	}
+
+	return ranked
+}
+
+func (n *Host) recordDialSuccess(addr ma.Multiaddr) {
+	n.familiesLock.Lock()
+	defer n.familiesLock.Unlock()
+
+	family := addrFamily(addr)
+	stat := n.families[family]
+	stat.Successes++
+	n.families[family] = stat
+	n.recordTransportDial(addr, true)
+}
+
+// recordDialFailure counts a failed dial against the family and transport of
+// addr.
+func (n *Host) recordDialFailure(addr ma.Multiaddr) {
+	n.familiesLock.Lock()
+	defer n.familiesLock.Unlock()
+
+	family := addrFamily(addr)
+	stat := n.families[family]
+	stat.Failures++
+	n.families[family] = stat
+	n.recordTransportDial(addr, false)
+}
+
+// dialTracer sees every address the swarm dials, so failures are learned
+// even when another address of the same peer connects. All events are also
+// passed on to the swarm's own metrics.
+type dialTracer struct {
+	swarm.MetricsTracer
+	n *Host
+}
+
+// traceDials installs the dial tracer on the swarm. libp2p sets its own
+// metrics tracer after any swarm options we pass, so ours replaces it once
+// the swarm is built, before it listens or dials. The tracer we wrap
+// reports the swarm metrics as libp2p's would, labelled by network.
+func (n *Host) traceDials(sw *swarm.Swarm) error {
+	tracer := swarm.NewMetricsTracer(swarm.WithRegisterer(n.metricsRegisterer()))
+	return swarm.WithMetricsTracer(&dialTracer{MetricsTracer: tracer, n: n})(sw)
+}
+
+func (t *dialTracer) FailedDialing(addr ma.Multiaddr, err, cause error) {
+	t.MetricsTracer.FailedDialing(addr, err, cause)
+	if errors.Is(err, context.Canceled) || errors.Is(cause, context.Canceled) {
+		// Another address connected first, this one didn't fail.
+		return
+	}
+	t.n.recordDialFailure(addr)
+}
+
+// addrFamily returns the name of the first protocol of an address, e.g.
+// "ip4", "ip6" or "dns".
+func addrFamily(addr ma.Multiaddr) string {
+	protocols := addr.Protocols()
+	if len(protocols) == 0 {
+		return "unknown"
+	}
+
+	return protocols[0].Name
+}
@@ -0,0 +1,54 @@
+package networking
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestRankDialAddrsLearnsFamilies(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	target := newTestHost(t, &config.Config{})
+
+	// The target only listens on IPv4, so dialing its IPv6 address fails
+	// while the IPv4 one connects.
+	ip6 := ma.StringCast(fmt.Sprintf("/ip6/::1/tcp/%d", freeTCPPort(t)))
+	ip4 := loopbackInfo(t, target).Addrs[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.connectWithPeer(ctx, peer.AddrInfo{ID: target.host.ID(), Addrs: []ma.Multiaddr{ip6, ip4}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		stats := n.AddressFamilyStats()
+		return stats["ip6"].Failures > 0 && stats["ip4"].Successes > 0
+	})
+
+	ranked := n.rankDialAddrs([]ma.Multiaddr{ip6, ip4})
+	if !ranked[0].Addr.Equal(ip4) {
+		t.Errorf("expected IPv4 to be dialed first, got %v", ranked)
+	}
+	if ranked[1].Delay <= ranked[0].Delay {
+		t.Errorf("expected IPv6 dial to be delayed, got %v", ranked)
+	}
+}
+
+func TestFailedConnectIsLearnedOnce(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.connectWithPeer(ctx, unreachablePeer(t)); err == nil {
+		t.Fatal("expected dialing an unreachable peer to fail")
+	}
+
+	if stats := n.AddressFamilyStats(); stats["ip4"].Failures != 1 {
+		t.Errorf("expected the failed IPv4 dial to be learned, got %+v", stats)
+	}
+}
@@ -0,0 +1,190 @@
+package networking
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// stallingPeer accepts TCP connections but never completes a handshake.
+func stallingPeer(t *testing.T) peer.AddrInfo {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	t.Cleanup(func() {
+		_ = l.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+
+	_, pub, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ma.StringCast(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", l.Addr().(*net.TCPAddr).Port))
+	return peer.AddrInfo{ID: pid, Addrs: []ma.Multiaddr{addr}}
+}
+
+// unreachablePeer returns a peer whose address refuses connections.
+func unreachablePeer(t *testing.T) peer.AddrInfo {
+	t.Helper()
+
+	// Nothing listens on a port we just released, so dialing it is refused.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	_, pub, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ma.StringCast(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", port))
+	return peer.AddrInfo{ID: pid, Addrs: []ma.Multiaddr{addr}}
+}
+
+func TestCancelDial(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	target := stallingPeer(t)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- n.connectWithPeer(context.Background(), target)
+	}()
+
+	waitFor(t, func() bool {
+		_, ok := n.InFlightDials()[target.ID]
+		return ok
+	})
+	if !n.CancelDial(target.ID) {
+		t.Fatal("expected in-flight dial to be cancelled")
+	}
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expected cancelled dial to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dial was not aborted")
+	}
+	if len(n.InFlightDials()) != 0 {
+		t.Errorf("expected no in-flight dials, got %v", n.InFlightDials())
+	}
+	if n.CancelDial(target.ID) {
+		t.Error("expected nothing to cancel once the dial finished")
+	}
+}
+
+func TestCancelConcurrentDials(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	target := stallingPeer(t)
+
+	errCh := make(chan error, 2)
+	for range 2 {
+		go func() {
+			errCh <- n.connectWithPeer(context.Background(), target)
+		}()
+	}
+
+	waitFor(t, func() bool {
+		n.dialsLock.Lock()
+		defer n.dialsLock.Unlock()
+		return len(n.dials[target.ID]) == 2
+	})
+	if !n.CancelDial(target.ID) {
+		t.Fatal("expected in-flight dials to be cancelled")
+	}
+
+	for range 2 {
+		select {
+		case err := <-errCh:
+			if err == nil {
+				t.Fatal("expected cancelled dial to fail")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("dial was not aborted")
+		}
+	}
+	if len(n.InFlightDials()) != 0 {
+		t.Errorf("expected no in-flight dials, got %v", n.InFlightDials())
+	}
+}
+
+func TestAddrDialTimeout(t *testing.T) {
+	const timeout = 500 * time.Millisecond
+	n := newTestHost(t, &config.Config{AddrDialTimeout: timeout})
+	target := stallingPeer(t)
+
+	// The swarm's own timeout for local dials is several seconds, so a dial
+	// failing well before that was cut off by AddrDialTimeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := n.connectWithPeer(ctx, target); err == nil {
+		t.Fatal("expected the handshake to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 4*timeout {
+		t.Errorf("expected the dial to fail after about %v, took %v", timeout, elapsed)
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	const timeout = 500 * time.Millisecond
+	n := newTestHost(t, &config.Config{HandshakeTimeout: timeout})
+	target := stallingPeer(t)
+
+	// Connecting succeeds at once, and the swarm's own timeout for local
+	// dials is several seconds, so a dial failing well before that was cut
+	// off by HandshakeTimeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := n.connectWithPeer(ctx, target); err == nil {
+		t.Fatal("expected the handshake to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 4*timeout {
+		t.Errorf("expected the dial to fail after about %v, took %v", timeout, elapsed)
+	}
+}
@@ -0,0 +1,150 @@
+package networking
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// discoveryDialWorkers is how many discovered peers are dialed at once.
+	discoveryDialWorkers = 16
+	// discoveryMinBackoff and discoveryMaxBackoff bound the wait before
+	// starting a discovery backend again after it failed to start.
+	discoveryMinBackoff = time.Second
+	discoveryMaxBackoff = 5 * time.Minute
+)
+
+// RestartDiscovery restarts the discovery backend and fetches the peer
+// registry right away, instead of waiting for its next refresh. It reports
+// whether any source took the request; sources that aren't running, or
+// that already have a restart pending, are skipped.
+func (n *Host) RestartDiscovery() bool {
+	restarted := false
+	for _, source := range []struct {
+		running *atomic.Bool
+		trigger chan struct{}
+	}{
+		{&n.discoveryRunning, n.discoveryRestart},
+		{&n.registryRunning, n.registryRefresh},
+	} {
+		if !source.running.Load() {
+			continue
+		}
+		select {
+		case source.trigger <- struct{}{}:
+			restarted = true
+		default:
+		}
+	}
+
+	return restarted
+}
+
+// listenForNewNodes runs the discovery backend and dials the peers it finds
+// until shutdown, when the backend is stopped. A backend that fails to start
+// is retried with exponential backoff, and one that closes its peers channel
+// waits to be restarted. RestartDiscovery stops and starts the backend again,
+// or skips the remaining backoff.
+func (n *Host) listenForNewNodes(backend config.DiscoveryBackend) {
+	defer n.discoveryRunning.Store(false)
+
+	backoff := time.Duration(0)
+	for {
+		if err := backend.Start(n.ctx); err != nil {
+			backoff = min(max(2*backoff, discoveryMinBackoff), discoveryMaxBackoff)
+			base.Log.Error("Failed to start discovery backend", "retryIn", backoff, "error", err)
+			if !n.waitForDiscoveryRestart(time.After(backoff)) {
+				return
+			}
+			continue
+		}
+		backoff = 0
+
+		ctx, cancel := context.WithCancel(n.ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			n.dialDiscoveredPeers(ctx, backend.Peers())
+		}()
+
+		restart := false
+		select {
+		case <-n.discoveryRestart:
+			restart = true
+		case <-done:
+		case <-n.ctx.Done():
+		}
+		cancel()
+		<-done
+
+		if err := backend.Stop(); err != nil {
+			base.Log.Warn("Failed to stop discovery backend", "error", err)
+		}
+		if n.ctx.Err() != nil {
+			return
+		}
+		if !restart {
+			base.Log.Warn("Discovery backend closed its peers channel, waiting for a restart")
+			if !n.waitForDiscoveryRestart(nil) {
+				return
+			}
+		}
+		base.Log.Info("Restarting discovery backend")
+	}
+}
+
+// waitForDiscoveryRestart blocks until RestartDiscovery is called or retry
+// fires. It returns false on shutdown.
+func (n *Host) waitForDiscoveryRestart(retry <-chan time.Time) bool {
+	select {
+	case <-n.discoveryRestart:
+		return true
+	case <-retry:
+		return true
+	case <-n.ctx.Done():
+		return false
+	}
+}
+
+// dialDiscoveredPeers dials the peers received on peers,
+// discoveryDialWorkers at a time, until peers is closed or ctx is done.
+func (n *Host) dialDiscoveredPeers(ctx context.Context, peers <-chan peer.AddrInfo) {
+	var wg sync.WaitGroup
+	for range discoveryDialWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case info, ok := <-peers:
+					if !ok {
+						return
+					}
+					n.dialDiscoveredPeer(info)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// dialDiscoveredPeer dials a peer found through the registry or a discovery
+// backend, unless it is us or already connected.
+func (n *Host) dialDiscoveredPeer(info peer.AddrInfo) {
+	if info.ID == n.host.ID() || n.host.Network().Connectedness(info.ID) == network.Connected {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(n.ctx, bootnodeDialTimeout)
+	defer cancel()
+	_ = n.connectWithPeer(ctx, info)
+}
@@ -0,0 +1,104 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// fakeDiscovery emits a fixed set of peers once started. The first
+// failStarts calls to Start fail.
+type fakeDiscovery struct {
+	peers      chan peer.AddrInfo
+	found      []peer.AddrInfo
+	starts     atomic.Int64
+	failStarts atomic.Int64
+	stopped    atomic.Bool
+}
+
+func (d *fakeDiscovery) Start(context.Context) error {
+	d.starts.Add(1)
+	if d.failStarts.Add(-1) >= 0 {
+		return errors.New("backend unavailable")
+	}
+	go func() {
+		for _, info := range d.found {
+			d.peers <- info
+		}
+	}()
+	return nil
+}
+
+func (d *fakeDiscovery) Stop() error {
+	d.stopped.Store(true)
+	return nil
+}
+
+func (d *fakeDiscovery) Peers() <-chan peer.AddrInfo {
+	return d.peers
+}
+
+func TestDiscoveryBackendPeersAreDialed(t *testing.T) {
+	first := newTestHost(t, &config.Config{})
+	second := newTestHost(t, &config.Config{})
+	backend := &fakeDiscovery{
+		peers: make(chan peer.AddrInfo),
+		found: []peer.AddrInfo{
+			{ID: first.host.ID(), Addrs: first.host.Addrs()},
+			{ID: second.host.ID(), Addrs: second.host.Addrs()},
+		},
+	}
+
+	n := newTestHost(t, &config.Config{DiscoveryBackend: backend})
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 2 })
+
+	n.cancel()
+	waitFor(t, backend.stopped.Load)
+}
+
+func TestDiscoveryBackendRetriedAfterFailedStart(t *testing.T) {
+	other := newTestHost(t, &config.Config{})
+	backend := &fakeDiscovery{
+		peers: make(chan peer.AddrInfo),
+		found: []peer.AddrInfo{{ID: other.host.ID(), Addrs: other.host.Addrs()}},
+	}
+	backend.failStarts.Store(1)
+
+	n := newTestHost(t, &config.Config{DiscoveryBackend: backend})
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+	if starts := backend.starts.Load(); starts != 2 {
+		t.Errorf("expected one failed and one successful start, got %d starts", starts)
+	}
+}
+
+func TestDiscoveryRestartAfterPeersChannelCloses(t *testing.T) {
+	backend := &fakeDiscovery{peers: make(chan peer.AddrInfo)}
+	close(backend.peers)
+
+	n := newTestHost(t, &config.Config{DiscoveryBackend: backend})
+	waitFor(t, backend.stopped.Load)
+
+	if !n.RestartDiscovery() {
+		t.Fatal("expected the restart to be delivered")
+	}
+	waitFor(t, func() bool { return backend.starts.Load() == 2 })
+}
+
+func TestDiscoveredPeerDialsAreBounded(t *testing.T) {
+	backend := &fakeDiscovery{peers: make(chan peer.AddrInfo)}
+	for range 2 * discoveryDialWorkers {
+		backend.found = append(backend.found, stallingPeer(t))
+	}
+
+	n := newTestHost(t, &config.Config{DiscoveryBackend: backend})
+	waitFor(t, func() bool { return len(n.InFlightDials()) == discoveryDialWorkers })
+	time.Sleep(100 * time.Millisecond)
+	if dials := len(n.InFlightDials()); dials != discoveryDialWorkers {
+		t.Errorf("expected %d dials at once, got %d", discoveryDialWorkers, dials)
+	}
+}
@@ -0,0 +1,114 @@
+package networking
+
+import (
+	"slices"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// connInfo is what the duplicate connection policy looks at.
+type connInfo struct {
+	transport string
+	opened    time.Time
+	// dialedByLower is set when the connection was dialed by whichever of
+	// the two peers has the lower ID, which both sides agree on.
+	dialedByLower bool
+}
+
+// simultaneousOpenWindow is how close together two connections may open for
+// the peers to disagree on which came first. KeepFirst treats them as a tie.
+const simultaneousOpenWindow = time.Second
+
+// keptConn returns the index of the connection to keep under the policy, or
+// -1 if all connections should be kept. After the transport preference, or
+// the opening order under KeepFirst, ties go to the connection dialed by the
+// lower peer ID, so both sides keep the same one even when they see the
+// connections open in a different order. Only connections dialed by the
+// same side fall back to the oldest.
+func keptConn(policy config.DuplicateConnectionPolicy, conns []connInfo) int {
+	var preferred string
+	switch policy {
+	case config.PreferQUIC:
+		preferred = "quic"
+	case config.PreferTCP:
+		preferred = "tcp"
+	case config.KeepFirst:
+	default:
+		return -1
+	}
+
+	keep := -1
+	for i, conn := range conns {
+		if keep == -1 {
+			keep = i
+			continue
+		}
+		current := conns[keep]
+		if preferred != "" && (conn.transport == preferred) != (current.transport == preferred) {
+			if conn.transport == preferred {
+				keep = i
+			}
+			continue
+		}
+		if policy == config.KeepFirst && !openedTogether(conn, current) {
+			if conn.opened.Before(current.opened) {
+				keep = i
+			}
+			continue
+		}
+		if conn.dialedByLower != current.dialedByLower {
+			if conn.dialedByLower {
+				keep = i
+			}
+			continue
+		}
+		if conn.opened.Before(current.opened) {
+			keep = i
+		}
+	}
+
+	return keep
+}
+
+// openedTogether reports whether a and b opened within
+// simultaneousOpenWindow of each other.
+func openedTogether(a, b connInfo) bool {
+	return a.opened.Sub(b.opened).Abs() < simultaneousOpenWindow
+}
+
+// closeDuplicateConns applies the duplicate connection policy to a peer.
+func (n *Host) closeDuplicateConns(net network.Network, pid peer.ID) {
+	// Probe connections are closed by TestDial itself.
+	conns := slices.DeleteFunc(net.ConnsToPeer(pid), n.isProbeConn)
+	if len(conns) < 2 {
+		return
+	}
+
+	localIsLower := net.LocalPeer() < pid
+	infos := make([]connInfo, len(conns))
+	for i, conn := range conns {
+		infos[i] = connInfo{
+			transport:     transportOf(conn.RemoteMultiaddr()),
+			opened:        conn.Stat().Opened,
+			dialedByLower: (conn.Stat().Direction == network.DirOutbound) == localIsLower,
+		}
+	}
+	keep := keptConn(n.config().DuplicateConnectionPolicy, infos)
+	if keep == -1 {
+		return
+	}
+
+	for i, conn := range conns {
+		if i == keep {
+			continue
+		}
+		base.Log.Debug("Closing duplicate connection", "peer", pid, "addr", conn.RemoteMultiaddr(), "policy", n.config().DuplicateConnectionPolicy)
+		go func() {
+			_ = conn.Close()
+		}()
+	}
+}
@@ -0,0 +1,89 @@
+package networking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+func TestKeptConn(t *testing.T) {
+	now := time.Now()
+	// A peer first connected over TCP and then again over QUIC.
+	conns := []connInfo{
+		{transport: "tcp", opened: now},
+		{transport: "quic", opened: now.Add(time.Second)},
+	}
+
+	tests := []struct {
+		policy config.DuplicateConnectionPolicy
+		want   int
+	}{
+		{config.KeepBoth, -1},
+		{config.PreferQUIC, 1},
+		{config.PreferTCP, 0},
+		{config.KeepFirst, 0},
+	}
+	for _, tt := range tests {
+		if got := keptConn(tt.policy, conns); got != tt.want {
+			t.Errorf("%s: expected to keep %d, got %d", tt.policy, tt.want, got)
+		}
+	}
+}
+
+func TestKeptConnFallsBackToOldest(t *testing.T) {
+	now := time.Now()
+	conns := []connInfo{
+		{transport: "tcp", opened: now.Add(time.Second)},
+		{transport: "tcp", opened: now},
+	}
+
+	if got := keptConn(config.PreferQUIC, conns); got != 1 {
+		t.Errorf("expected the oldest connection to be kept, got %d", got)
+	}
+}
+
+func TestKeptConnAgreesOnBothSides(t *testing.T) {
+	now := time.Now()
+	// Both peers dialed each other at once, so each sees its own outbound
+	// connection open first. Only the one dialed by the lower peer ID
+	// survives on both sides.
+	lower := []connInfo{
+		{transport: "tcp", opened: now, dialedByLower: true},
+		{transport: "tcp", opened: now.Add(time.Millisecond)},
+	}
+	higher := []connInfo{
+		{transport: "tcp", opened: now},
+		{transport: "tcp", opened: now.Add(time.Millisecond), dialedByLower: true},
+	}
+
+	for _, policy := range []config.DuplicateConnectionPolicy{config.KeepFirst, config.PreferQUIC, config.PreferTCP} {
+		if got := keptConn(policy, lower); got != 0 {
+			t.Errorf("%s: expected the lower peer to keep its outbound connection, got %d", policy, got)
+		}
+		if got := keptConn(policy, higher); got != 1 {
+			t.Errorf("%s: expected the higher peer to keep its inbound connection, got %d", policy, got)
+		}
+	}
+}
+
+func TestKeepFirstKeepsOldest(t *testing.T) {
+	now := time.Now()
+	// The higher peer connected first; the lower peer dialed a second
+	// connection well after. Both sides see the same order, so the first
+	// connection is kept.
+	conns := []connInfo{
+		{transport: "tcp", opened: now},
+		{transport: "tcp", opened: now.Add(2 * simultaneousOpenWindow), dialedByLower: true},
+	}
+	if got := keptConn(config.KeepFirst, conns); got != 0 {
+		t.Errorf("expected the first connection to be kept, got %d", got)
+	}
+
+	// Opened together, the order is not reliable and the lower peer's
+	// connection is kept.
+	conns[1].opened = now.Add(simultaneousOpenWindow / 2)
+	if got := keptConn(config.KeepFirst, conns); got != 1 {
+		t.Errorf("expected the connection dialed by the lower peer to be kept, got %d", got)
+	}
+}
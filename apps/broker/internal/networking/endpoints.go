@@ -0,0 +1,54 @@
+package networking
+
+import (
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// LocalEndpointInfo describes how other peers can reach this host, e.g. for
+// registering it with a coordinator.
+type LocalEndpointInfo struct {
+	PeerID           string   `json:"peer_id,omitempty"`
+	ListenAddrs      []string `json:"listen_addrs"`
+	AdvertisedAddrs  []string `json:"advertised_addrs"`
+	HostAddress      string   `json:"host_address,omitempty"`
+	ExternalTCPAddr  string   `json:"external_tcp_addr,omitempty"`
+	ExternalQUICAddr string   `json:"external_quic_addr,omitempty"`
+	// Partial is set until the host is started and the first round of
+	// bootnode dials has finished, as the addresses may still change.
+	Partial bool `json:"partial"`
+}
+
+// LocalEndpoints returns the host's peer ID and addresses. Before Init only
+// the configured external addresses are known.
+func (n *Host) LocalEndpoints() LocalEndpointInfo {
+	cfg := n.config()
+	info := LocalEndpointInfo{HostAddress: cfg.HostAddress, Partial: true}
+	if cfg.HostAddress != "" {
+		for _, listen := range n.listenAddrs() {
+			external, err := n.externalAddr(ma.StringCast(listen))
+			if err != nil {
+				base.Log.Debug("Could not build external address", "addr", listen, "error", err)
+				continue
+			}
+			if _, err := external.ValueForProtocol(ma.P_QUIC_V1); err == nil {
+				info.ExternalQUICAddr = external.String()
+			} else {
+				info.ExternalTCPAddr = external.String()
+			}
+		}
+	}
+	if n.host == nil {
+		return info
+	}
+
+	info.PeerID = n.host.ID().String()
+	info.ListenAddrs = addrStrings(n.host.Network().ListenAddresses())
+	info.AdvertisedAddrs = addrStrings(n.host.Addrs())
+
+	n.bootnodesLock.RLock()
+	info.Partial = n.bootnodeResults == nil
+	n.bootnodesLock.RUnlock()
+
+	return info
+}
@@ -0,0 +1,41 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+func TestLocalEndpoints(t *testing.T) {
+	cfg := &config.Config{
+		QUICPort:         freeUDPPort(t),
+		HostAddress:      "203.0.113.7",
+		ExternalTCPPort:  5001,
+		ExternalQUICPort: 5002,
+	}
+
+	before := NewHost(cfg).LocalEndpoints()
+	if !before.Partial || before.PeerID != "" || before.ExternalTCPAddr != "/ip4/203.0.113.7/tcp/5001" {
+		t.Errorf("expected partial info with the configured addresses before start, got %+v", before)
+	}
+
+	n := newTestHost(t, cfg)
+	waitFor(t, func() bool { return !n.LocalEndpoints().Partial })
+
+	info := n.LocalEndpoints()
+	if info.PeerID != n.host.ID().String() {
+		t.Errorf("expected peer ID %s, got %s", n.host.ID(), info.PeerID)
+	}
+	if len(info.ListenAddrs) == 0 || len(info.AdvertisedAddrs) == 0 {
+		t.Errorf("expected listen and advertised addresses, got %+v", info)
+	}
+	if info.HostAddress != cfg.HostAddress {
+		t.Errorf("expected host address %s, got %s", cfg.HostAddress, info.HostAddress)
+	}
+	if info.ExternalTCPAddr != "/ip4/203.0.113.7/tcp/5001" {
+		t.Errorf("unexpected external TCP address %s", info.ExternalTCPAddr)
+	}
+	if info.ExternalQUICAddr != "/ip4/203.0.113.7/udp/5002/quic-v1" {
+		t.Errorf("unexpected external QUIC address %s", info.ExternalQUICAddr)
+	}
+}
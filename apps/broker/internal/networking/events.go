@@ -0,0 +1,138 @@
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// peerEventBuffer is how many events may queue for a slow sink before
+	// new ones are dropped.
+	peerEventBuffer = 256
+	// peerEventHistory is how many recent events are kept for
+	// DumpPeerEvents.
+	peerEventHistory = 1024
+)
+
+// PeerEventType is the kind of peer lifecycle event.
+type PeerEventType string
+
+const (
+	PeerConnected    PeerEventType = "connected"
+	PeerDisconnected PeerEventType = "disconnected"
+)
+
+// PeerEvent is a peer connecting or disconnecting. Direction is only set for
+// connects.
+type PeerEvent struct {
+	Peer      peer.ID           `json:"peer"`
+	Type      PeerEventType     `json:"type"`
+	Direction network.Direction `json:"direction,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// SetPeerEventSink delivers every peer lifecycle event to sink from a single
+// goroutine. Events are buffered so a slow sink never blocks connection
+// handling; when the buffer is full they are dropped and counted. Passing nil
+// stops delivery.
+func (n *Host) SetPeerEventSink(sink func(PeerEvent)) {
+	n.eventsLock.Lock()
+	defer n.eventsLock.Unlock()
+
+	n.eventSink = sink
+}
+
+// PeerEventsDropped returns how many events were dropped because the sink
+// fell behind.
+func (n *Host) PeerEventsDropped() int64 {
+	return n.eventsDropped.Load()
+}
+
+// DumpPeerEvents writes the recent peer events, oldest first, as
+// newline-delimited JSON.
+func (n *Host) DumpPeerEvents(w io.Writer) error {
+	history := n.recentEvents()
+
+	enc := json.NewEncoder(w)
+	for _, evt := range history {
+		if err := enc.Encode(evt); err != nil {
+			return fmt.Errorf("failed to write peer event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// dumpPeerEventsToFile writes the recent peer events to PeerEventDumpPath.
+func (n *Host) dumpPeerEventsToFile() error {
+	f, err := os.Create(n.config().PeerEventDumpPath)
+	if err != nil {
+		return err
+	}
+	if err := n.DumpPeerEvents(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// recentEvents returns a copy of the event history, oldest first.
+func (n *Host) recentEvents() []PeerEvent {
+	n.eventsLock.RLock()
+	defer n.eventsLock.RUnlock()
+
+	if len(n.history) < peerEventHistory {
+		return append([]PeerEvent(nil), n.history...)
+	}
+
+	return append(append([]PeerEvent(nil), n.history[n.historyNext:]...), n.history[:n.historyNext]...)
+}
+
+// emitPeerEvent records evt and queues it for the sink. The queueing happens
+// under the same lock as the history append, so the sink sees events in the
+// order they are recorded.
+func (n *Host) emitPeerEvent(evt PeerEvent) {
+	n.eventsLock.Lock()
+	defer n.eventsLock.Unlock()
+
+	if len(n.history) < peerEventHistory {
+		n.history = append(n.history, evt)
+	} else {
+		n.history[n.historyNext] = evt
+		n.historyNext = (n.historyNext + 1) % peerEventHistory
+	}
+	if n.eventSink == nil {
+		return
+	}
+
+	select {
+	case n.events <- evt:
+	default:
+		n.eventsDropped.Add(1)
+		peerEventsDropped.WithLabelValues(n.config().NetworkName).Inc()
+	}
+}
+
+// deliverPeerEvents forwards queued events to the sink until shutdown.
+func (n *Host) deliverPeerEvents() {
+	for {
+		select {
+		case evt := <-n.events:
+			n.eventsLock.RLock()
+			sink := n.eventSink
+			n.eventsLock.RUnlock()
+			if sink != nil {
+				sink(evt)
+			}
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package networking
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPeerEventSink(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	other := newTestHost(t, &config.Config{})
+
+	events := make(chan PeerEvent, 2)
+	n.SetPeerEventSink(func(evt PeerEvent) { events <- evt })
+
+	connectHosts(t, n, other)
+	_ = n.host.Network().ClosePeer(other.host.ID())
+
+	for _, want := range []PeerEventType{PeerConnected, PeerDisconnected} {
+		select {
+		case evt := <-events:
+			if evt.Type != want || evt.Peer != other.host.ID() || evt.Time.IsZero() {
+				t.Fatalf("unexpected event %+v, want %s", evt, want)
+			}
+			if want == PeerConnected && evt.Direction != network.DirOutbound {
+				t.Errorf("expected outbound direction, got %s", evt.Direction)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event delivered", want)
+		}
+	}
+}
+
+func TestPeerEventSinkDropsWhenFull(t *testing.T) {
+	n := NewHost(&config.Config{})
+	n.SetPeerEventSink(func(PeerEvent) {})
+
+	// Nothing is delivering, so the buffer fills up.
+	for i := 0; i < peerEventBuffer+3; i++ {
+		n.emitPeerEvent(PeerEvent{Type: PeerConnected, Time: time.Now()})
+	}
+	if n.PeerEventsDropped() != 3 {
+		t.Errorf("expected 3 dropped events, got %d", n.PeerEventsDropped())
+	}
+}
+
+func TestPeerEventSinkMatchesHistoryOrder(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	const total = 64
+	delivered := make(chan PeerEvent, total)
+	n.SetPeerEventSink(func(evt PeerEvent) { delivered <- evt })
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			n.emitPeerEvent(PeerEvent{Type: PeerConnected, Time: time.Unix(int64(i), 0)})
+		}(i)
+	}
+	wg.Wait()
+
+	history := n.recentEvents()
+	if len(history) != total {
+		t.Fatalf("expected %d events in the history, got %d", total, len(history))
+	}
+	for i, want := range history {
+		select {
+		case evt := <-delivered:
+			if !evt.Time.Equal(want.Time) {
+				t.Fatalf("event %d delivered out of order: got %v, want %v", i, evt.Time, want.Time)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d not delivered", i)
+		}
+	}
+}
+
+func TestDumpPeerEventsRoundTrip(t *testing.T) {
+	n := NewHost(&config.Config{})
+	now := time.Now()
+	total := peerEventHistory + 2
+	peers := make([]peer.ID, total)
+	for i := range peers {
+		_, pub, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if peers[i], err = peer.IDFromPublicKey(pub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < total; i++ {
+		n.emitPeerEvent(PeerEvent{
+			Peer:      peers[i],
+			Type:      PeerConnected,
+			Direction: network.DirInbound,
+			Time:      now.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := n.DumpPeerEvents(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var dumped []PeerEvent
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var evt PeerEvent
+		if err := dec.Decode(&evt); err != nil {
+			t.Fatal(err)
+		}
+		dumped = append(dumped, evt)
+	}
+
+	// The two oldest events fell out of the ring.
+	if len(dumped) != peerEventHistory {
+		t.Fatalf("expected %d events, got %d", peerEventHistory, len(dumped))
+	}
+	for i, evt := range dumped {
+		want := i + 2
+		if evt.Peer != peers[want] || evt.Type != PeerConnected ||
+			evt.Direction != network.DirInbound || !evt.Time.Equal(now.Add(time.Duration(want)*time.Second)) {
+			t.Fatalf("event %d did not round-trip: %+v", i, evt)
+		}
+	}
+}
+
+func TestPeerEventsDumpedOnStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peer-events.jsonl")
+	n := newTestHost(t, &config.Config{PeerEventDumpPath: path})
+	other := newTestHost(t, &config.Config{})
+	connectHosts(t, n, other)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.GracefulStop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(other.host.ID().String())) {
+		t.Errorf("expected the dump to contain the connected peer, got %s", data)
+	}
+}
@@ -0,0 +1,163 @@
+package networking
+
+import (
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/time/rate"
+)
+
+// gater is the connection gater installed on the libp2p host.
+type gater struct {
+	n *Host
+}
+
+func (g *gater) InterceptPeerDial(pid peer.ID) bool {
+	if g.n.isBlockedPeer(pid) {
+		return false
+	}
+	if limit := g.n.config().MaxOutboundPeers; limit > 0 && g.n.peerCount(network.DirOutbound) >= limit {
+		base.Log.Debug("Outbound dial rejected, peer limit reached", "peer", pid, "limit", limit)
+		return false
+	}
+	if !g.n.hasPeerSlot(pid) {
+		base.Log.Debug("Outbound dial rejected, no peer slots left", "peer", pid, "limit", g.n.config().MaxPeerCount)
+		return false
+	}
+
+	return true
+}
+
+func (g *gater) InterceptAddrDial(pid peer.ID, addr ma.Multiaddr) bool {
+	if !g.n.isAllowedAddr(addr) {
+		return false
+	}
+	if g.n.asnFull(pid, addr) {
+		base.Log.Debug("Outbound dial rejected, ASN peer limit reached", "peer", pid, "addr", addr)
+		return false
+	}
+
+	return true
+}
+
+// InterceptAccept runs before any crypto, so the global handshake rate is
+// enforced here.
+func (g *gater) InterceptAccept(addrs network.ConnMultiaddrs) bool {
+	if g.n.inboundPaused.Load() {
+		base.Log.Debug("Inbound connection rejected, inbound is paused", "addr", addrs.RemoteMultiaddr())
+		return false
+	}
+	if !g.n.isAllowedAddr(addrs.RemoteMultiaddr()) {
+		return false
+	}
+	if !g.n.handshakes.Allow() {
+		base.Log.Debug("Inbound connection rejected, handshake rate exceeded", "addr", addrs.RemoteMultiaddr())
+		return false
+	}
+
+	return true
+}
+
+// InterceptSecured is the first point at which the remote peer ID of an
+// inbound connection is known, so admission hooks are consulted here.
+func (g *gater) InterceptSecured(dir network.Direction, pid peer.ID, addrs network.ConnMultiaddrs) bool {
+	if g.n.isBlockedPeer(pid) {
+		base.Log.Debug("Connection rejected, peer is blocklisted", "peer", pid)
+		return false
+	}
+	if dir != network.DirInbound {
+		return true
+	}
+
+	if limit := g.n.config().MaxInboundPeers; limit > 0 && !g.n.hasInboundConn(pid) && g.n.peerCount(network.DirInbound) >= limit {
+		base.Log.Debug("Inbound connection rejected, peer limit reached", "peer", pid, "limit", limit)
+		return false
+	}
+	if !g.n.hasPeerSlot(pid) {
+		base.Log.Debug("Inbound connection rejected, no peer slots left", "peer", pid, "limit", g.n.config().MaxPeerCount)
+		return false
+	}
+	if g.n.asnFull(pid, addrs.RemoteMultiaddr()) {
+		base.Log.Debug("Inbound connection rejected, ASN peer limit reached", "peer", pid, "addr", addrs.RemoteMultiaddr())
+		return false
+	}
+
+	if admit := g.n.config().InboundAdmission; admit != nil && !admit(addrs.RemoteMultiaddr(), pid) {
+		base.Log.Debug("Inbound connection rejected by admission hook", "peer", pid, "addr", addrs.RemoteMultiaddr())
+		return false
+	}
+
+	return true
+}
+
+func (g *gater) InterceptUpgraded(_ network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+// newHandshakeLimiter returns a limiter for inbound handshakes, or no limit
+// when perSec is zero.
+func newHandshakeLimiter(perSec float64, burst int) *rate.Limiter {
+	if perSec <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	return rate.NewLimiter(rate.Limit(perSec), max(burst, 1))
+}
+
+// setHandshakeLimit changes the limit of a limiter from newHandshakeLimiter
+// in place, like setByteLimit.
+func setHandshakeLimit(limiter *rate.Limiter, perSec float64, burst int) {
+	if perSec <= 0 {
+		limiter.SetLimit(rate.Inf)
+		return
+	}
+
+	limiter.SetLimit(rate.Limit(perSec))
+	limiter.SetBurst(max(burst, 1))
+}
+
+// isAllowedAddr reports whether addr passes both the blocklist and the
+// configured address filter. With AddrFilterOverridesBlocklist the filter
+// alone decides.
+func (n *Host) isAllowedAddr(addr ma.Multiaddr) bool {
+	cfg := n.config()
+	if cfg.AddrFilter != nil && cfg.AddrFilterOverridesBlocklist {
+		return cfg.AddrFilter(addr)
+	}
+
+	if n.isBlockedAddr(addr) {
+		return false
+	}
+	if cfg.AddrFilter != nil && !cfg.AddrFilter(addr) {
+		return false
+	}
+
+	return true
+}
+
+// peerCount returns the number of connected peers with at least one
+// connection in the given direction.
+func (n *Host) peerCount(dir network.Direction) int {
+	peers := make(map[peer.ID]struct{})
+	for _, conn := range n.host.Network().Conns() {
+		if conn.Stat().Direction == dir {
+			peers[conn.RemotePeer()] = struct{}{}
+		}
+	}
+
+	return len(peers)
+}
+
+// hasInboundConn reports whether pid already has an inbound connection, and
+// so already counts against MaxInboundPeers.
+func (n *Host) hasInboundConn(pid peer.ID) bool {
+	for _, conn := range n.host.Network().ConnsToPeer(pid) {
+		if conn.Stat().Direction == network.DirInbound {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,249 @@
+package networking
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+func TestInboundAdmission(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		window   time.Time
+		admitted int
+	)
+	// Admit at most one new peer per second.
+	admission := func(_ ma.Multiaddr, _ peer.ID) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		if now.Sub(window) >= time.Second {
+			window = now
+			admitted = 0
+		}
+		if admitted >= 1 {
+			return false
+		}
+		admitted++
+		return true
+	}
+
+	server := newTestHost(t, &config.Config{InboundAdmission: admission})
+	first := newTestHost(t, &config.Config{})
+	second := newTestHost(t, &config.Config{})
+
+	connectHosts(t, first, server)
+	// The second inbound connection within the same second is rejected.
+	expectRejected(t, second, server)
+}
+
+// expectRejected dials server from client and waits for the connection to be
+// dropped. The dialer's Connect can return before the server's gater rejects
+// the secured connection, so only the outcome is checked.
+func expectRejected(t *testing.T, client, server *Host) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info := peer.AddrInfo{ID: server.host.ID(), Addrs: server.host.Addrs()}
+	if err := client.host.Connect(ctx, info); err != nil {
+		return
+	}
+	waitFor(t, func() bool {
+		return client.host.Network().Connectedness(server.host.ID()) != network.Connected &&
+			server.host.Network().Connectedness(client.host.ID()) != network.Connected
+	})
+}
+
+func TestInboundPeerLimit(t *testing.T) {
+	server := newTestHost(t, &config.Config{MaxInboundPeers: 1})
+	first := newTestHost(t, &config.Config{})
+	second := newTestHost(t, &config.Config{})
+	outbound := newTestHost(t, &config.Config{})
+
+	connectHosts(t, first, server)
+	// The dialer can finish before the server has registered its side.
+	waitFor(t, func() bool { return server.peerCount(network.DirInbound) == 1 })
+	// The inbound connection above the limit is rejected.
+	expectRejected(t, second, server)
+
+	// Outbound dialing is unaffected by the inbound cap.
+	connectHosts(t, server, outbound)
+}
+
+func TestOutboundPeerLimit(t *testing.T) {
+	client := newTestHost(t, &config.Config{MaxOutboundPeers: 1})
+	first := newTestHost(t, &config.Config{})
+	second := newTestHost(t, &config.Config{})
+
+	connectHosts(t, client, first)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info := peer.AddrInfo{ID: second.host.ID(), Addrs: second.host.Addrs()}
+	if err := client.host.Connect(ctx, info); err == nil {
+		t.Fatal("expected outbound dial above the limit to be rejected")
+	}
+
+	// Inbound connections are still accepted.
+	connectHosts(t, second, client)
+}
+
+func TestAddrFilter(t *testing.T) {
+	isLoopback := func(addr ma.Multiaddr) bool {
+		ip, err := manet.ToIP(addr)
+		return err == nil && ip.IsLoopback()
+	}
+
+	server := newTestHost(t, &config.Config{})
+	rejecting := newTestHost(t, &config.Config{
+		AddrFilter: func(addr ma.Multiaddr) bool { return !isLoopback(addr) },
+	})
+	permitting := newTestHost(t, &config.Config{AddrFilter: isLoopback})
+
+	var local []ma.Multiaddr
+	for _, addr := range server.host.Addrs() {
+		if isLoopback(addr) {
+			local = append(local, addr)
+		}
+	}
+	if len(local) == 0 {
+		t.Skip("server has no loopback address")
+	}
+	info := peer.AddrInfo{ID: server.host.ID(), Addrs: local}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rejecting.host.Connect(ctx, info); err == nil {
+		t.Fatal("expected the filter to reject loopback addresses")
+	}
+	if err := permitting.host.Connect(ctx, info); err != nil {
+		t.Fatalf("expected the filter to permit loopback addresses: %v", err)
+	}
+}
+
+func TestAddrFilterOverridesBlocklist(t *testing.T) {
+	isLoopback := func(addr ma.Multiaddr) bool {
+		ip, err := manet.ToIP(addr)
+		return err == nil && ip.IsLoopback()
+	}
+
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte("127.0.0.0/8\n::1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newTestHost(t, &config.Config{})
+	blocked := newTestHost(t, &config.Config{BlocklistPath: path, AddrFilter: isLoopback})
+	overriding := newTestHost(t, &config.Config{
+		BlocklistPath:                path,
+		AddrFilter:                   isLoopback,
+		AddrFilterOverridesBlocklist: true,
+	})
+
+	info := loopbackInfo(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := blocked.host.Connect(ctx, info); err == nil {
+		t.Fatal("expected the blocklist to reject loopback addresses by default")
+	}
+	if err := overriding.host.Connect(ctx, info); err != nil {
+		t.Fatalf("expected the overriding filter to permit blocklisted loopback addresses: %v", err)
+	}
+}
+
+func TestInboundHandshakeRate(t *testing.T) {
+	server := newTestHost(t, &config.Config{MaxInboundHandshakeRate: 0.1, MaxInboundHandshakeBurst: 2})
+
+	info := loopbackInfo(t, server)
+
+	accepted := 0
+	for i := 0; i < 5; i++ {
+		client := newTestHost(t, &config.Config{})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := client.host.Connect(ctx, info); err == nil {
+			accepted++
+		}
+		cancel()
+	}
+	if accepted != 2 {
+		t.Errorf("expected only the burst of 2 connections to be accepted, got %d", accepted)
+	}
+}
+
+func TestMaxPeersPerASN(t *testing.T) {
+	// All test hosts share one AS, whichever local address they dial from.
+	resolver := func(net.IP) (uint32, bool) {
+		return 64500, true
+	}
+	server := newTestHost(t, &config.Config{ASNResolver: resolver, MaxPeersPerASN: 2})
+	first := newTestHost(t, &config.Config{})
+	second := newTestHost(t, &config.Config{})
+	third := newTestHost(t, &config.Config{})
+	info := loopbackInfo(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, client := range []*Host{first, second} {
+		if err := client.host.Connect(ctx, info); err != nil {
+			t.Fatalf("expected peers up to the AS limit to be accepted: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return server.ConnectedPeerCount() == 2 })
+
+	// At the limit, a peer already connected may open another connection
+	// without counting against itself.
+	pid := first.host.ID()
+	twin, err := libp2p.New(
+		libp2p.Identity(first.host.Peerstore().PrivKey(pid)),
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.PrometheusRegisterer(first.metricsRegisterer()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = twin.Close() })
+	if err := twin.Connect(ctx, info); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(server.host.Network().ConnsToPeer(pid)) == 2 })
+
+	// A new peer from the same AS is over the limit.
+	expectRejected(t, third, server)
+	if server.ConnectedPeerCount() != 2 {
+		t.Errorf("expected the peers at the limit to stay connected, got %d", server.ConnectedPeerCount())
+	}
+
+	// Without a resolver nothing is limited.
+	open := newTestHost(t, &config.Config{MaxPeersPerASN: 1})
+	connectHosts(t, first, open)
+	connectHosts(t, second, open)
+}
+
+// loopbackInfo returns h's address info restricted to a single loopback
+// address, so that the remote side sees us at 127.0.0.1.
+func loopbackInfo(t *testing.T, h *Host) peer.AddrInfo {
+	t.Helper()
+
+	for _, addr := range h.host.Addrs() {
+		if ip, err := manet.ToIP(addr); err == nil && ip.IsLoopback() {
+			return peer.AddrInfo{ID: h.host.ID(), Addrs: []ma.Multiaddr{addr}}
+		}
+	}
+	t.Skip("host has no loopback address")
+
+	return peer.AddrInfo{}
+}
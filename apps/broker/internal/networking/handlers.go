@@ -0,0 +1,151 @@
+package networking
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// streamTracker counts the stream handlers that are still running.
+type streamTracker struct {
+	mu     sync.Mutex
+	active map[protocol.ID]int
+	// changed is closed and replaced whenever a handler finishes.
+	changed chan struct{}
+}
+
+func newStreamTracker() *streamTracker {
+	return &streamTracker{
+		active:  make(map[protocol.ID]int),
+		changed: make(chan struct{}),
+	}
+}
+
+func (t *streamTracker) start(pid protocol.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active[pid]++
+}
+
+func (t *streamTracker) done(pid protocol.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active[pid]--
+	if t.active[pid] <= 0 {
+		delete(t.active, pid)
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// count returns the number of running handlers for a protocol, or for all
+// protocols when pid is empty.
+func (t *streamTracker) count(pid protocol.ID) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if pid != "" {
+		return t.active[pid]
+	}
+	total := 0
+	for _, c := range t.active {
+		total += c
+	}
+
+	return total
+}
+
+// wait blocks until no handlers for pid (or any protocol when empty) are
+// running, or ctx is done.
+func (t *streamTracker) wait(ctx context.Context, pid protocol.ID) error {
+	for {
+		t.mu.Lock()
+		changed := t.changed
+		t.mu.Unlock()
+
+		if t.count(pid) == 0 {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ShutdownContext is cancelled as soon as shutdown begins. Stream handlers
+// should select on it to stop taking on new work while finishing what they
+// have in hand.
+func (n *Host) ShutdownContext() context.Context {
+	return n.ctx
+}
+
+// setStreamHandler registers a stream handler wrapped so that it is tracked
+// for graceful shutdown and subject to the protocol's deadlines. Streams
+// opened once shutdown has begun are reset.
+func (n *Host) setStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	n.host.SetStreamHandler(pid, func(s network.Stream) {
+		if n.ctx.Err() != nil {
+			_ = s.Reset()
+			return
+		}
+
+		remote := s.Conn().RemotePeer()
+		n.markActive(remote)
+		defer n.markActive(remote)
+		n.streams.start(pid)
+		defer n.streams.done(pid)
+
+		handler(n.withDeadlines(n.throttle(s), pid))
+	})
+}
+
+// drainingMessage is sent to peers opening a stream on a draining protocol.
+const drainingMessage = "draining"
+
+// DrainProtocol stops accepting new streams on protocolID, waits for the
+// handlers already running to finish until ctx is done, and then removes the
+// protocol. New streams are answered with a "draining" error chunk meanwhile.
+// The protocol is removed even if ctx ends first, leaving the handlers still
+// running to finish on their own.
+func (n *Host) DrainProtocol(ctx context.Context, protocolID protocol.ID) error {
+	n.host.SetStreamHandler(protocolID, func(s network.Stream) {
+		defer s.Close()
+		_ = WriteErrorChunk(s, drainingMessage)
+	})
+	defer n.host.RemoveStreamHandler(protocolID)
+
+	if err := n.streams.wait(ctx, protocolID); err != nil {
+		return fmt.Errorf("failed to drain %s: %w", protocolID, err)
+	}
+
+	return nil
+}
+
+// GracefulStop signals shutdown to all stream handlers, waits for running
+// handlers to finish until ctx is done, and then closes the host.
+func (n *Host) GracefulStop(ctx context.Context) error {
+	n.cancel()
+	n.detachReachabilityCallbacks()
+
+	if err := n.streams.wait(ctx, ""); err != nil {
+		base.Log.Warn("Stream handlers did not finish before shutdown deadline", "active", n.streams.count(""))
+	}
+	if cfg := n.config(); cfg.PeerEventDumpPath != "" {
+		if err := n.dumpPeerEventsToFile(); err != nil {
+			base.Log.Error("Failed to dump peer events", "path", cfg.PeerEventDumpPath, "error", err)
+		}
+	}
+
+	n.hardCancel()
+
+	return n.host.Close()
+}
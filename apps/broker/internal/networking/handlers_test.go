@@ -0,0 +1,168 @@
+package networking
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestGracefulStopSignalsHandlers(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+
+	started := make(chan struct{})
+	observed := make(chan struct{})
+	server.setStreamHandler("/test/slow/1.0.0", func(s network.Stream) {
+		defer s.Close()
+		close(started)
+		select {
+		case <-server.ShutdownContext().Done():
+			close(observed)
+		case <-time.After(5 * time.Second):
+		}
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := client.host.NewStream(ctx, server.host.ID(), "/test/slow/1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if err := server.GracefulStop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-observed:
+	default:
+		t.Fatal("handler did not observe shutdown")
+	}
+	if server.streams.count("") != 0 {
+		t.Error("expected no active handlers after graceful stop")
+	}
+}
+
+func TestDrainProtocol(t *testing.T) {
+	const proto = "/test/drain/1.0.0"
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var opened atomic.Int64
+	server.setStreamHandler(proto, func(s network.Stream) {
+		// Only the first stream stays active; probes racing the drain are reset.
+		if opened.Add(1) > 1 {
+			_ = s.Reset()
+			return
+		}
+		defer s.Close()
+		close(started)
+		<-release
+		_ = WriteChunks(s, []encoding.BinaryMarshaler{&testItem{"done"}})
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	active, err := client.host.NewStream(ctx, server.host.ID(), proto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer active.Close()
+	if _, err := active.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	drained := make(chan error, 1)
+	go func() { drained <- server.DrainProtocol(ctx, proto) }()
+	waitFor(t, func() bool {
+		rejected, err := client.host.NewStream(ctx, server.host.ID(), proto)
+		if err != nil {
+			return false
+		}
+		defer rejected.Close()
+		_, _ = rejected.Write([]byte{1})
+		var chunkErr *ChunkError
+		err = ReadChunks(rejected, func() encoding.BinaryUnmarshaler { return &testItem{} }, func(encoding.BinaryUnmarshaler) error { return nil })
+		return errors.As(err, &chunkErr) && chunkErr.Message == drainingMessage
+	})
+
+	select {
+	case err := <-drained:
+		t.Fatalf("drain finished with a stream still active: %v", err)
+	default:
+	}
+
+	close(release)
+	var values []string
+	err = ReadChunks(active,
+		func() encoding.BinaryUnmarshaler { return &testItem{} },
+		func(item encoding.BinaryUnmarshaler) error {
+			values = append(values, item.(*testItem).value)
+			return nil
+		},
+	)
+	if err != nil || len(values) != 1 || values[0] != "done" {
+		t.Fatalf("expected the active stream to complete, got %v, %v", values, err)
+	}
+
+	if err := <-drained; err != nil {
+		t.Fatal(err)
+	}
+	// NewStream negotiates lazily, so check the server's protocols directly.
+	if slices.Contains(server.host.Mux().Protocols(), proto) {
+		t.Error("expected the protocol to be removed after draining")
+	}
+}
+
+func TestDrainProtocolRemovesHandlerOnTimeout(t *testing.T) {
+	const proto = "/test/drain/1.0.0"
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server.setStreamHandler(proto, func(s network.Stream) {
+		defer s.Close()
+		close(started)
+		<-release
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	active, err := client.host.NewStream(ctx, server.host.ID(), proto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer active.Close()
+	if _, err := active.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer drainCancel()
+	if err := server.DrainProtocol(drainCtx, proto); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the drain to time out, got %v", err)
+	}
+	if slices.Contains(server.host.Mux().Protocols(), proto) {
+		t.Error("expected the protocol to be removed after the drain timed out")
+	}
+}
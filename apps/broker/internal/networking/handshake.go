@@ -0,0 +1,60 @@
+package networking
+
+import (
+	"context"
+	"time"
+
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	libp2pwebrtc "github.com/libp2p/go-libp2p/p2p/transport/webrtc"
+	ws "github.com/libp2p/go-libp2p/p2p/transport/websocket"
+	webtransport "github.com/libp2p/go-libp2p/p2p/transport/webtransport"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// handshakeTransports are libp2p's default transports with TCP upgrading
+// its connections through handshakeUpgrader.
+func (n *Host) handshakeTransports() libp2p.Option {
+	return libp2p.ChainOptions(
+		libp2p.Transport(n.newTCPTransport),
+		libp2p.Transport(libp2pquic.NewTransport),
+		libp2p.Transport(ws.New),
+		libp2p.Transport(webtransport.New),
+		libp2p.Transport(libp2pwebrtc.New),
+	)
+}
+
+func (n *Host) newTCPTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager) (*tcp.TcpTransport, error) {
+	return tcp.NewTCPTransport(n.withHandshakeTimeout(upgrader), rcmgr, nil)
+}
+
+// handshakeUpgrader bounds the upgrade of outbound connections, the security
+// and muxer handshakes after the transport connected, by HandshakeTimeout.
+// Inbound connections are upgraded by the listener, which keeps libp2p's
+// accept timeout.
+type handshakeUpgrader struct {
+	transport.Upgrader
+	timeout time.Duration
+}
+
+// withHandshakeTimeout wraps upgrader in a handshakeUpgrader when
+// HandshakeTimeout is set.
+func (n *Host) withHandshakeTimeout(upgrader transport.Upgrader) transport.Upgrader {
+	timeout := n.config().HandshakeTimeout
+	if timeout <= 0 {
+		return upgrader
+	}
+
+	return &handshakeUpgrader{Upgrader: upgrader, timeout: timeout}
+}
+
+func (u *handshakeUpgrader) Upgrade(ctx context.Context, t transport.Transport, maconn manet.Conn, dir network.Direction, p peer.ID, scope network.ConnManagementScope) (transport.CapableConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	return u.Upgrader.Upgrade(ctx, t, maconn, dir, p, scope)
+}
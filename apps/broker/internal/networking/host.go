@@ -4,27 +4,192 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
 	"github.com/flinkcoin/mono/libs/shared/pkg/base"
 	libp2p "github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
-	"github.com/libp2p/go-libp2p/p2p/security/noise"
-	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
 	ma "github.com/multiformats/go-multiaddr"
+	"go.uber.org/fx"
+	"golang.org/x/time/rate"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	connMgrLowWater    = 100
+	connMgrHighWater   = 400
+	connMgrGracePeriod = time.Minute
+)
+
 type Host struct {
-	host host.Host
+	// cfg is replaced as a whole by ReloadConfig, so it is only read
+	// through config. reloadLock serializes reloads.
+	reloadLock sync.Mutex
+	cfg        atomic.Pointer[config.Config]
+	// reloaded is closed and replaced by every ReloadConfig, waking the
+	// loops whose interval comes from the config.
+	reloaded atomic.Pointer[chan struct{}]
+
+	host   host.Host
+	limits rcmgr.ConcreteLimitConfig
+
+	// ctx is cancelled when shutdown begins. hardCtx is only cancelled once
+	// the host is closed, after running handlers had the chance to finish.
+	ctx        context.Context
+	cancel     context.CancelFunc
+	hardCtx    context.Context
+	hardCancel context.CancelFunc
+	streams    *streamTracker
+	requests   *requestLimiter
+	// ingress and egress throttle protocol streams and handshakes limits
+	// inbound connections. Their limits are rate.Inf while disabled and are
+	// updated in place by ReloadConfig.
+	ingress    *rate.Limiter
+	egress     *rate.Limiter
+	handshakes *rate.Limiter
+
+	// bandwidth counts traffic per peer for TransportStats.
+	bandwidth *metrics.BandwidthCounter
+
+	addrsLock sync.Mutex
+	// nat is the NAT manager's mapping lookup; nil until port mapping starts.
+	nat      natMapping
+	natAddrs map[string]struct{}
+
+	peersLock    sync.RWMutex
+	connectedAt  map[peer.ID]time.Time
+	lastActivity map[peer.ID]time.Time
+	uptimes      map[peer.ID]*peerUptime
+
+	dialsLock sync.Mutex
+	dials     map[peer.ID][]*inFlightDial
+
+	probesLock sync.Mutex
+	// probes counts the TestDial calls in progress per peer; probeConns
+	// holds the IDs of the connections they opened. selfProbes holds the
+	// hosts dialing our own addresses.
+	probes     map[peer.ID]int
+	probeConns map[string]struct{}
+	selfProbes map[peer.ID]struct{}
+
+	familiesLock   sync.Mutex
+	families       map[string]AddressFamilyStat
+	transportDials map[string]AddressFamilyStat
+
+	blocklistLock sync.RWMutex
+	blocklist     *blocklist
+	// blocklistWatch starts the single SIGHUP watcher.
+	blocklistWatch sync.Once
+
+	eventsLock    sync.RWMutex
+	eventSink     func(PeerEvent)
+	events        chan PeerEvent
+	eventsDropped atomic.Int64
+	// history is a ring of the most recent events; historyNext is the
+	// oldest entry once it is full.
+	history     []PeerEvent
+	historyNext int
+
+	churnLock sync.Mutex
+	churn     ChurnSnapshot
+
+	slotsLock    sync.Mutex
+	reservations map[peer.ID]*slotReservation
+
+	directionsLock sync.Mutex
+	directions     map[peer.ID]*connDirections
+
+	latencyLock sync.Mutex
+	latencies   map[peer.ID][]time.Duration
+
+	bootnodesLock    sync.RWMutex
+	bootnodeResults  []BootnodeResult
+	bootnodeFailures map[string]int
+
+	reachabilityLock      sync.RWMutex
+	reachability          network.Reachability
+	reachabilityCallbacks []func(network.Reachability)
+
+	observedLock sync.RWMutex
+	observed     map[peer.ID]ma.Multiaddr
+	// observedMismatch is set while enough peers agree on a different IP.
+	observedMismatch bool
+
+	startupLock sync.Mutex
+	startedAt   time.Time
+	firstPeerAt time.Time
+
+	// discoveryRestart and registryRefresh carry RestartDiscovery requests
+	// to the discovery backend and the registry watcher, which set
+	// discoveryRunning and registryRunning while they are around to read
+	// them.
+	discoveryRestart chan struct{}
+	registryRefresh  chan struct{}
+	discoveryRunning atomic.Bool
+	registryRunning  atomic.Bool
+
+	isolationRestarts atomic.Int64
+	verboseLogging    atomic.Bool
+	inboundPaused     atomic.Bool
 }
 
-func NewHost() *Host {
+func NewHost(cfg *config.Config) *Host {
+	ctx, cancel := context.WithCancel(context.Background())
+	hardCtx, hardCancel := context.WithCancel(context.Background())
+	n := &Host{
+		hardCtx:          hardCtx,
+		hardCancel:       hardCancel,
+		ctx:              ctx,
+		cancel:           cancel,
+		streams:          newStreamTracker(),
+		requests:         newRequestLimiter(cfg.MaxConcurrentRequestsPerProtocol),
+		ingress:          newByteLimiter(cfg.MaxIngressBytesPerSec),
+		egress:           newByteLimiter(cfg.MaxEgressBytesPerSec),
+		handshakes:       newHandshakeLimiter(cfg.MaxInboundHandshakeRate, cfg.MaxInboundHandshakeBurst),
+		connectedAt:      make(map[peer.ID]time.Time),
+		lastActivity:     make(map[peer.ID]time.Time),
+		uptimes:          make(map[peer.ID]*peerUptime),
+		dials:            make(map[peer.ID][]*inFlightDial),
+		probes:           make(map[peer.ID]int),
+		probeConns:       make(map[string]struct{}),
+		selfProbes:       make(map[peer.ID]struct{}),
+		families:         make(map[string]AddressFamilyStat),
+		transportDials:   make(map[string]AddressFamilyStat),
+		bandwidth:        metrics.NewBandwidthCounter(),
+		observed:         make(map[peer.ID]ma.Multiaddr),
+		reservations:     make(map[peer.ID]*slotReservation),
+		events:           make(chan PeerEvent, peerEventBuffer),
+		directions:       make(map[peer.ID]*connDirections),
+		latencies:        make(map[peer.ID][]time.Duration),
+		bootnodeFailures: make(map[string]int),
+		discoveryRestart: make(chan struct{}, 1),
+		registryRefresh:  make(chan struct{}, 1),
+	}
 
-	return &Host{}
+	n.cfg.Store(cfg)
+	reloaded := make(chan struct{})
+	n.reloaded.Store(&reloaded)
+
+	if cfg.BlocklistPath != "" {
+		n.reloadBlocklist()
+		n.watchBlocklist()
+	}
+
+	return n
+}
+
+// config returns the current configuration. It must not be modified.
+func (n *Host) config() *config.Config {
+	return n.cfg.Load()
 }
 
 func (n *Host) Init() {
@@ -35,40 +200,96 @@ func (n *Host) Init() {
 	// Let's create a second host setting some more options.
 
 	// Set your own keypair
-	priv, _, err := crypto.GenerateKeyPair(
-		crypto.Ed25519, // Select your key type. Ed25519 are nice short
-		-1,             // Select key length when possible (i.e. RSA).
-	)
+	priv, err := n.privKey()
 	if err != nil {
 		panic(err)
 	}
 
 	connmgr, err := connmgr.NewConnManager(
-		100, // Lowwater
-		400, // HighWater,
-		connmgr.WithGracePeriod(time.Minute),
+		connMgrLowWater,
+		connMgrHighWater,
+		connmgr.WithGracePeriod(connMgrGracePeriod),
 	)
 	if err != nil {
 		panic(err)
 	}
-	n.host, err = libp2p.New(
+
+	// Scale the default resource limits to this machine, keeping them
+	// around so they can be reported.
+	scalingLimits := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&scalingLimits)
+	n.limits = scalingLimits.AutoScale()
+	rm, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(n.limits))
+	if err != nil {
+		panic(err)
+	}
+
+	n.markStarted()
+	n.host, err = libp2p.New(n.buildOptions(priv, connmgr, rm)...)
+	if err != nil {
+		panic(err)
+	}
+
+	n.host.Network().Notify(n.notifiee())
+	if err := n.watchIdentify(); err != nil {
+		panic(err)
+	}
+	if err := n.watchReachability(); err != nil {
+		panic(err)
+	}
+
+	base.Log.Info("Hello World, my second hosts ID is %s\n", "hostKey:", n.host.ID())
+
+	n.startListener()
+	n.updateLogVerbosity()
+
+	cfg := n.config()
+	go n.deliverPeerEvents()
+	go n.dialInitialPeers()
+	if cfg.RegistryURL != "" && cfg.RegistryRefreshInterval > 0 {
+		n.registryRunning.Store(true)
+		go n.watchRegistry()
+	}
+	if cfg.DiscoveryBackend != nil {
+		n.discoveryRunning.Store(true)
+		go n.listenForNewNodes(cfg.DiscoveryBackend)
+	}
+	// These loops wait for a reload while their setting is zero.
+	go n.isolationWatchdog()
+	go n.disconnectIdlePeers()
+	go n.sampleLatencies()
+	go n.watchAsymmetry()
+}
+
+// buildOptions assembles the libp2p options for the host from our config.
+func (n *Host) buildOptions(priv crypto.PrivKey, cm *connmgr.BasicConnMgr, rm network.ResourceManager) []libp2p.Option {
+	opts := []libp2p.Option{
 		// Use the keypair we generated
 		libp2p.Identity(priv),
 		// Multiple listen addresses
-		libp2p.ListenAddrStrings(
-			"/ip4/0.0.0.0/tcp/0",
-		),
-		// support TLS connections_
-		libp2p.Security(libp2ptls.ID, libp2ptls.New),
-		// support noise connections
-		libp2p.Security(noise.ID, noise.New),
-		// support any other default transports (TCP)
-		libp2p.DefaultTransports,
+		libp2p.ListenAddrStrings(n.listenAddrs()...),
+		// Advertise external addresses when running behind a NAT.
+		libp2p.AddrsFactory(n.advertisedAddrs),
+		// support TLS and noise connections
+		securityOptions(),
+		// support any other default transports (TCP), or only TCP through
+		// the SOCKS5 proxy
+		n.transports(),
+		// Try address families that usually work first and bound dials.
+		libp2p.SwarmOpts(n.swarmOptions()...),
+		// Learn from every address that fails to dial.
+		libp2p.WithFxOption(fx.Invoke(n.traceDials)),
+		// Label libp2p's metrics with the network like ours.
+		libp2p.PrometheusRegisterer(n.metricsRegisterer()),
 		// Let's prevent our peer from having too many
 		// connections by attaching a connection manager.
-		libp2p.ConnectionManager(connmgr),
-		// Attempt to open ports using uPNP for NATed hosts.
-		libp2p.NATPortMap(),
+		libp2p.ConnectionManager(cm),
+		// Count traffic per peer for transport statistics.
+		libp2p.BandwidthReporter(n.bandwidth),
+		// Enforce resource limits on connections, streams and memory.
+		libp2p.ResourceManager(rm),
+		// Gate connections through our own admission policies.
+		libp2p.ConnectionGater(&gater{n: n}),
 
 		// If you want to help other peers to figure out if they are behind
 		// NATs, you can launch the server-side of AutoNAT too (AutoRelay
@@ -77,14 +298,25 @@ func (n *Host) Init() {
 		// This service is highly rate-limited and should not cause any
 		// performance issues.
 		libp2p.EnableNATService(),
-	)
-	if err != nil {
-		panic(err)
+	}
+	if n.config().EnableNATPortMap {
+		// Attempt to open ports using uPNP for NATed hosts.
+		opts = append(opts, libp2p.NATManager(n.newNATManager))
 	}
 
-	base.Log.Info("Hello World, my second hosts ID is %s\n", "hostKey:", n.host.ID())
+	return opts
+}
+
+// swarmOptions returns the swarm options: our dial ranker, and
+// AddrDialTimeout in place of the swarm's per-address dial timeouts when it
+// is set.
+func (n *Host) swarmOptions() []swarm.Option {
+	opts := []swarm.Option{swarm.WithDialRanker(n.rankDialAddrs)}
+	if timeout := n.config().AddrDialTimeout; timeout > 0 {
+		opts = append(opts, swarm.WithDialTimeout(timeout), swarm.WithDialTimeoutLocal(timeout))
+	}
 
-	startListener(context.Background(), n.host)
+	return opts
 }
 
 func getHostAddress(ha host.Host) string {
@@ -97,13 +329,13 @@ func getHostAddress(ha host.Host) string {
 	return addr.Encapsulate(hostAddr).String()
 }
 
-func startListener(ctx context.Context, ha host.Host) {
-	fullAddr := getHostAddress(ha)
+func (n *Host) startListener() {
+	fullAddr := getHostAddress(n.host)
 	log.Printf("I am %s\n", fullAddr)
 
 	// Set a stream handler on host A. /echo/1.0.0 is
 	// a user-defined protocol name.
-	ha.SetStreamHandler("/echo/1.0.0", func(s network.Stream) {
+	n.setStreamHandler("/echo/1.0.0", func(s network.Stream) {
 		log.Println("listener received new stream")
 		if err := doEcho(s); err != nil {
 			log.Println(err)
@@ -131,7 +363,7 @@ func (n *Host) Connect(peerek string) {
 	}
 
 	// Connect to the peer
-	if err := n.host.Connect(context.Background(), *peerInfo); err != nil {
+	if err := n.connectWithPeer(context.Background(), *peerInfo); err != nil {
 		log.Fatal(err)
 	}
 
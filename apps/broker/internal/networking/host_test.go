@@ -0,0 +1,48 @@
+package networking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func newTestHost(t *testing.T, cfg *config.Config) *Host {
+	t.Helper()
+
+	n := NewHost(cfg)
+	n.Init()
+	t.Cleanup(func() {
+		n.cancel()
+		n.hardCancel()
+		_ = n.host.Close()
+	})
+
+	return n
+}
+
+func connectHosts(t *testing.T, a, b *Host) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info := peer.AddrInfo{ID: b.host.ID(), Addrs: b.host.Addrs()}
+	if err := a.host.Connect(ctx, info); err != nil {
+		t.Fatalf("failed to connect hosts: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
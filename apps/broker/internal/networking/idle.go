@@ -0,0 +1,117 @@
+package networking
+
+import (
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// markActive records that a peer did something useful.
+func (n *Host) markActive(pid peer.ID) {
+	n.peersLock.Lock()
+	defer n.peersLock.Unlock()
+
+	if _, ok := n.connectedAt[pid]; ok {
+		n.lastActivity[pid] = time.Now()
+	}
+}
+
+// minSweepInterval is the shortest interval at which the background loops
+// check for idle or isolated hosts, however short their timeout.
+const minSweepInterval = 10 * time.Millisecond
+
+// sweepInterval returns how often a condition with the given timeout is
+// checked, or zero when the timeout is disabled.
+func sweepInterval(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return 0
+	}
+
+	return max(timeout/2, minSweepInterval)
+}
+
+// waitInterval sleeps for the interval that interval returns for the current
+// config and reports false once the host shuts down. A reload restarts the
+// wait with the new interval, and while the interval is zero it only waits
+// for a reload.
+func (n *Host) waitInterval(interval func(*config.Config) time.Duration) bool {
+	for {
+		reloaded := *n.reloaded.Load()
+		wait := interval(n.config())
+		var timer *time.Timer
+		var tick <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			tick = timer.C
+		}
+
+		select {
+		case <-tick:
+			return true
+		case <-reloaded:
+		case <-n.ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if n.ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// idlePeers returns the connected peers that have been inactive for longer
+// than timeout. Peers with open streams, e.g. in the middle of a long
+// transfer, are never idle.
+func (n *Host) idlePeers(timeout time.Duration) []peer.ID {
+	n.peersLock.RLock()
+	var candidates []peer.ID
+	for pid, last := range n.lastActivity {
+		if time.Since(last) > timeout {
+			candidates = append(candidates, pid)
+		}
+	}
+	n.peersLock.RUnlock()
+
+	var idle []peer.ID
+	for _, pid := range candidates {
+		if !n.hasOpenStreams(pid) {
+			idle = append(idle, pid)
+		}
+	}
+
+	return idle
+}
+
+func (n *Host) hasOpenStreams(pid peer.ID) bool {
+	for _, conn := range n.host.Network().ConnsToPeer(pid) {
+		if len(conn.GetStreams()) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// disconnectIdlePeers periodically closes connections to peers idle for
+// longer than IdlePeerTimeout.
+func (n *Host) disconnectIdlePeers() {
+	for n.waitInterval(func(cfg *config.Config) time.Duration { return sweepInterval(cfg.IdlePeerTimeout) }) {
+		timeout := n.config().IdlePeerTimeout
+		if timeout <= 0 {
+			continue
+		}
+
+		for _, pid := range n.idlePeers(timeout) {
+			if n.host.ConnManager().IsProtected(pid, "") {
+				continue
+			}
+			base.Log.Debug("Disconnecting idle peer", "peer", pid, "timeout", timeout)
+			if err := n.host.Network().ClosePeer(pid); err != nil {
+				base.Log.Debug("Failed to disconnect idle peer", "peer", pid, "error", err)
+			}
+		}
+	}
+}
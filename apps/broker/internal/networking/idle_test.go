@@ -0,0 +1,128 @@
+package networking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestIdlePeersAreDisconnected(t *testing.T) {
+	server := newTestHost(t, &config.Config{IdlePeerTimeout: 200 * time.Millisecond})
+	idle := newTestHost(t, &config.Config{})
+	protected := newTestHost(t, &config.Config{})
+
+	connectHosts(t, idle, server)
+	connectHosts(t, protected, server)
+	server.host.ConnManager().Protect(protected.host.ID(), "test")
+
+	waitFor(t, func() bool { return server.ConnectedPeerCount() == 1 })
+	if _, ok := server.PeerConnectedAddr(protected.host.ID()); !ok {
+		t.Error("expected protected peer to stay connected")
+	}
+}
+
+func TestIdleStaticPeerStaysConnected(t *testing.T) {
+	static := newTestHost(t, &config.Config{})
+	info := loopbackInfo(t, static)
+	addrs, err := peer.AddrInfoToP2pAddrs(&info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{IdlePeerTimeout: 200 * time.Millisecond, StaticPeers: []string{addrs[0].String()}}
+	n := newTestHost(t, cfg)
+	waitFor(t, func() bool { return n.host.ConnManager().IsProtected(static.host.ID(), staticPeerTag) })
+
+	time.Sleep(600 * time.Millisecond)
+	if n.ConnectedPeerCount() != 1 {
+		t.Fatal("expected the idle static peer to stay connected")
+	}
+
+	// Once a reload removes it, it is disconnected like any other peer.
+	next := *cfg
+	next.StaticPeers = nil
+	if err := n.ReloadConfig(&next); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 0 })
+}
+
+func TestIdleBootnodeStaysConnected(t *testing.T) {
+	bootnode := newTestHost(t, &config.Config{})
+	info := loopbackInfo(t, bootnode)
+	addrs, err := peer.AddrInfoToP2pAddrs(&info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{IdlePeerTimeout: 200 * time.Millisecond, Bootnodes: []string{addrs[0].String()}}
+	n := newTestHost(t, cfg)
+	waitFor(t, func() bool { return n.host.ConnManager().IsProtected(bootnode.host.ID(), bootnodeTag) })
+
+	time.Sleep(600 * time.Millisecond)
+	if n.ConnectedPeerCount() != 1 {
+		t.Fatal("expected the idle bootnode to stay connected")
+	}
+
+	next := *cfg
+	next.Bootnodes = nil
+	if err := n.ReloadConfig(&next); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 0 })
+}
+
+func TestActivePeersStayConnected(t *testing.T) {
+	server := newTestHost(t, &config.Config{IdlePeerTimeout: 300 * time.Millisecond})
+	client := newTestHost(t, &config.Config{})
+	server.setStreamHandler(testRequestProtocol, echoHandler(t, 0, nil, nil))
+	connectHosts(t, client, server)
+
+	for i := 0; i < 8; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		var resp testItem
+		err := client.Request(ctx, server.host.ID(), testRequestProtocol, &testItem{"ping"}, &resp)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if server.ConnectedPeerCount() != 1 {
+		t.Error("expected active peer to stay connected")
+	}
+}
+
+func TestPeerInLongTransferStaysConnected(t *testing.T) {
+	server := newTestHost(t, &config.Config{IdlePeerTimeout: 200 * time.Millisecond})
+	client := newTestHost(t, &config.Config{})
+	// The stream stays open for several idle timeouts.
+	server.setStreamHandler(testSlowProtocol, slowHandler(time.Second))
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var resp testItem
+	if err := client.Request(ctx, server.host.ID(), testSlowProtocol, &testItem{"bulk"}, &resp); err != nil {
+		t.Fatalf("expected the long request to complete, got %v", err)
+	}
+	if server.ConnectedPeerCount() != 1 {
+		t.Error("expected the peer to stay connected during the transfer")
+	}
+}
+
+func TestSweepIntervalIsClamped(t *testing.T) {
+	if got := sweepInterval(time.Nanosecond); got != minSweepInterval {
+		t.Errorf("expected a 1ns timeout to be checked every %v, got %v", minSweepInterval, got)
+	}
+	if got := sweepInterval(time.Minute); got != 30*time.Second {
+		t.Errorf("expected half the timeout, got %v", got)
+	}
+
+	// A tiny timeout must not panic the background loop.
+	newTestHost(t, &config.Config{IdlePeerTimeout: time.Nanosecond})
+}
@@ -0,0 +1,23 @@
+package networking
+
+import "github.com/flinkcoin/mono/libs/shared/pkg/base"
+
+// PauseInbound rejects new inbound connections until ResumeInbound is called.
+// Connected peers and outbound dials are unaffected.
+func (n *Host) PauseInbound() {
+	if !n.inboundPaused.Swap(true) {
+		base.Log.Info("Inbound connections paused")
+	}
+}
+
+// ResumeInbound accepts inbound connections again after PauseInbound.
+func (n *Host) ResumeInbound() {
+	if n.inboundPaused.Swap(false) {
+		base.Log.Info("Inbound connections resumed")
+	}
+}
+
+// InboundPaused reports whether inbound connections are currently rejected.
+func (n *Host) InboundPaused() bool {
+	return n.inboundPaused.Load()
+}
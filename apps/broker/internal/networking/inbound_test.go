@@ -0,0 +1,42 @@
+package networking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestPauseInbound(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	existing := newTestHost(t, &config.Config{})
+	rejected := newTestHost(t, &config.Config{})
+	outbound := newTestHost(t, &config.Config{})
+
+	connectHosts(t, existing, server)
+
+	server.PauseInbound()
+	if !server.InboundPaused() {
+		t.Fatal("expected inbound to be reported as paused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rejected.host.Connect(ctx, loopbackInfo(t, server)); err == nil {
+		t.Fatal("expected inbound connections to be rejected while paused")
+	}
+	if server.host.Network().Connectedness(existing.host.ID()) != network.Connected {
+		t.Error("expected existing peers to stay connected")
+	}
+	// Outbound dialing is unaffected by the pause.
+	connectHosts(t, server, outbound)
+
+	server.ResumeInbound()
+	if server.InboundPaused() {
+		t.Fatal("expected inbound to be reported as resumed")
+	}
+	// The rejected peer's dial is in backoff, so connect from a fresh peer.
+	connectHosts(t, newTestHost(t, &config.Config{}), server)
+}
@@ -0,0 +1,69 @@
+package networking
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+var ErrPrivateKeyMissing = errors.New("private key file is missing")
+
+// privKey loads the host key from PrivateKeyPath. Without a path a new key is
+// generated on every start; when the file does not exist KeyMissingBehavior
+// decides whether to generate one, generate and persist one, or fail.
+func (n *Host) privKey() (crypto.PrivKey, error) {
+	path := n.config().PrivateKeyPath
+	if path == "" {
+		return generateKey()
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		priv, err := crypto.UnmarshalPrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode private key %s: %w", path, err)
+		}
+		return priv, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read private key %s: %w", path, err)
+	}
+
+	switch n.config().KeyMissingBehavior {
+	case config.KeyFail:
+		return nil, fmt.Errorf("%w: %s", ErrPrivateKeyMissing, path)
+	case config.KeyGenerateAndPersist:
+		priv, err := generateKey()
+		if err != nil {
+			return nil, err
+		}
+		data, err := crypto.MarshalPrivateKey(priv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to persist private key %s: %w", path, err)
+		}
+		base.Log.Info("Generated and persisted a new private key", "path", path)
+		return priv, nil
+	case config.KeyGenerate, "":
+		base.Log.Warn("Private key file is missing, using a new key for this run", "path", path)
+		return generateKey()
+	default:
+		return nil, fmt.Errorf("unknown key missing behavior %q", n.config().KeyMissingBehavior)
+	}
+}
+
+func generateKey() (crypto.PrivKey, error) {
+	priv, _, err := crypto.GenerateKeyPair(
+		crypto.Ed25519, // Select your key type. Ed25519 are nice short
+		-1,             // Select key length when possible (i.e. RSA).
+	)
+
+	return priv, err
+}
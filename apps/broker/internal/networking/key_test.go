@@ -0,0 +1,73 @@
+package networking
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func TestPrivKeyMissingGenerate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.key")
+	n := NewHost(&config.Config{PrivateKeyPath: path, KeyMissingBehavior: config.KeyGenerate})
+
+	if _, err := n.privKey(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected no key file to be written")
+	}
+}
+
+func TestPrivKeyMissingGenerateAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.key")
+	n := NewHost(&config.Config{PrivateKeyPath: path, KeyMissingBehavior: config.KeyGenerateAndPersist})
+
+	priv, err := n.privKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted, err := crypto.UnmarshalPrivateKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !persisted.Equals(priv) {
+		t.Error("expected the persisted key to match the generated one")
+	}
+
+	again, err := n.privKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Equals(priv) {
+		t.Error("expected the persisted key to be reused")
+	}
+}
+
+func TestPrivKeyMissingFail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.key")
+	n := NewHost(&config.Config{PrivateKeyPath: path, KeyMissingBehavior: config.KeyFail})
+
+	if _, err := n.privKey(); !errors.Is(err, ErrPrivateKeyMissing) {
+		t.Fatalf("expected ErrPrivateKeyMissing, got %v", err)
+	}
+}
+
+func TestPrivKeyMissingUnknownBehavior(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.key")
+	n := NewHost(&config.Config{PrivateKeyPath: path, KeyMissingBehavior: "regenerate"})
+
+	if _, err := n.privKey(); err == nil {
+		t.Fatal("expected an unknown behavior to be rejected")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected no key file to be written")
+	}
+}
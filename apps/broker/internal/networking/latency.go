@@ -0,0 +1,122 @@
+package networking
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+const (
+	// latencyWindow is how many recent RTT samples are kept per peer.
+	latencyWindow = 100
+	// minLatencySamples is how many samples are needed for percentiles.
+	minLatencySamples = 5
+	// latencyPingTimeout bounds a single ping.
+	latencyPingTimeout = 10 * time.Second
+	// latencyPingWorkers is how many peers are pinged at once.
+	latencyPingWorkers = 16
+)
+
+// PeerLatencyPercentiles returns the 50th, 95th and 99th percentile of the
+// peer's recent round-trip times. It reports false until enough samples have
+// been collected.
+func (n *Host) PeerLatencyPercentiles(pid peer.ID) (p50, p95, p99 time.Duration, ok bool) {
+	n.latencyLock.Lock()
+	samples := slices.Clone(n.latencies[pid])
+	n.latencyLock.Unlock()
+
+	if len(samples) < minLatencySamples {
+		return 0, 0, 0, false
+	}
+	slices.Sort(samples)
+
+	return percentile(samples, 0.50), percentile(samples, 0.95), percentile(samples, 0.99), true
+}
+
+// percentile returns the nearest-rank percentile of sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+
+	return sorted[max(rank-1, 0)]
+}
+
+// recordLatency adds an RTT sample, dropping the oldest once the window is
+// full.
+func (n *Host) recordLatency(pid peer.ID, rtt time.Duration) {
+	n.latencyLock.Lock()
+	defer n.latencyLock.Unlock()
+
+	n.appendLatency(pid, rtt)
+}
+
+// appendLatency adds an RTT sample. latencyLock must be held.
+func (n *Host) appendLatency(pid peer.ID, rtt time.Duration) {
+	samples := append(n.latencies[pid], rtt)
+	if len(samples) > latencyWindow {
+		samples = samples[len(samples)-latencyWindow:]
+	}
+	n.latencies[pid] = samples
+	peerLatency.WithLabelValues(n.config().NetworkName).Observe(rtt.Seconds())
+}
+
+// forgetLatency drops the samples of a peer that disconnected.
+func (n *Host) forgetLatency(pid peer.ID) {
+	n.latencyLock.Lock()
+	defer n.latencyLock.Unlock()
+
+	delete(n.latencies, pid)
+}
+
+// sampleLatencies pings every connected peer each LatencySampleInterval,
+// latencyPingWorkers at a time. The interval is counted from the end of the
+// previous round.
+func (n *Host) sampleLatencies() {
+	for n.waitInterval(func(cfg *config.Config) time.Duration { return cfg.LatencySampleInterval }) {
+		pids := n.host.Network().Peers()
+		queue := make(chan peer.ID)
+		var wg sync.WaitGroup
+		for range min(latencyPingWorkers, len(pids)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for pid := range queue {
+					n.pingPeer(pid)
+				}
+			}()
+		}
+		for _, pid := range pids {
+			queue <- pid
+		}
+		close(queue)
+		wg.Wait()
+	}
+}
+
+func (n *Host) pingPeer(pid peer.ID) {
+	// Don't re-dial peers that disconnected since the round started.
+	ctx, cancel := context.WithTimeout(network.WithNoDial(n.ctx, "latency sample"), latencyPingTimeout)
+	defer cancel()
+
+	res := <-ping.Ping(ctx, n.host, pid)
+	if res.Error != nil {
+		base.Log.Debug("Failed to ping peer", "peer", pid, "error", res.Error)
+		return
+	}
+
+	// Check under latencyLock, so a peer that disconnected while we waited
+	// for the pong isn't given samples after forgetLatency dropped them.
+	n.latencyLock.Lock()
+	defer n.latencyLock.Unlock()
+	if n.host.Network().Connectedness(pid) != network.Connected {
+		return
+	}
+	n.appendLatency(pid, res.RTT)
+}
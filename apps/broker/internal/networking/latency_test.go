@@ -0,0 +1,73 @@
+package networking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPeerLatencyPercentiles(t *testing.T) {
+	n := NewHost(&config.Config{})
+	pid := peer.ID("peer")
+
+	for i := 1; i < minLatencySamples; i++ {
+		n.recordLatency(pid, time.Millisecond)
+	}
+	if _, _, _, ok := n.PeerLatencyPercentiles(pid); ok {
+		t.Fatal("expected too few samples to report no percentiles")
+	}
+
+	// Fill the window with 1ms..100ms, pushing out the earlier samples.
+	for i := 100; i >= 1; i-- {
+		n.recordLatency(pid, time.Duration(i)*time.Millisecond)
+	}
+	p50, p95, p99, ok := n.PeerLatencyPercentiles(pid)
+	if !ok {
+		t.Fatal("expected percentiles once enough samples are recorded")
+	}
+	if p50 != 50*time.Millisecond || p95 != 95*time.Millisecond || p99 != 99*time.Millisecond {
+		t.Errorf("unexpected percentiles p50=%s p95=%s p99=%s", p50, p95, p99)
+	}
+}
+
+func TestLatencySampling(t *testing.T) {
+	n := newTestHost(t, &config.Config{LatencySampleInterval: 20 * time.Millisecond})
+	other := newTestHost(t, &config.Config{})
+	connectHosts(t, n, other)
+
+	waitFor(t, func() bool {
+		_, _, _, ok := n.PeerLatencyPercentiles(other.host.ID())
+		return ok
+	})
+}
+
+func TestPingPeerSkipsDisconnectedPeers(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	other := newTestHost(t, &config.Config{})
+	connectHosts(t, n, other)
+
+	n.pingPeer(other.host.ID())
+	n.latencyLock.Lock()
+	samples := len(n.latencies[other.host.ID()])
+	n.latencyLock.Unlock()
+	if samples != 1 {
+		t.Fatalf("expected a sample for a connected peer, got %d", samples)
+	}
+
+	_ = n.host.Network().ClosePeer(other.host.ID())
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 0 })
+	n.forgetLatency(other.host.ID())
+
+	// The ping must neither re-dial the peer nor store a sample for it.
+	n.pingPeer(other.host.ID())
+	if n.ConnectedPeerCount() != 0 {
+		t.Error("expected the latency ping not to re-dial the peer")
+	}
+	n.latencyLock.Lock()
+	defer n.latencyLock.Unlock()
+	if _, ok := n.latencies[other.host.ID()]; ok {
+		t.Error("expected no samples for a disconnected peer")
+	}
+}
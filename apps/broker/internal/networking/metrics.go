@@ -0,0 +1,79 @@
+package networking
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// networkLabel carries the configured network name on every metric.
+const networkLabel = "network"
+
+// metricNames lists the name of every metric registered by this package.
+var metricNames []string
+
+var (
+	observedAddrMismatch = newGaugeVec(prometheus.GaugeOpts{
+		Name: "p2p_observed_address_mismatch",
+		Help: "Set to 1 when peers observe us at an IP other than the configured host address.",
+	})
+	timeToFirstPeer = newGaugeVec(prometheus.GaugeOpts{
+		Name: "p2p_time_to_first_peer_seconds",
+		Help: "Seconds between host startup and the first peer connecting.",
+	})
+	peerEventsDropped = newCounterVec(prometheus.CounterOpts{
+		Name: "p2p_peer_events_dropped_total",
+		Help: "Peer lifecycle events dropped because the event sink fell behind.",
+	})
+	transportDials = newCounterVec(prometheus.CounterOpts{
+		Name: "p2p_transport_dials_total",
+		Help: "Dials per transport, e.g. tcp or quic, by result.",
+	}, "transport", "result")
+	streamBytes = newCounterVec(prometheus.CounterOpts{
+		Name: "p2p_stream_bytes_total",
+		Help: "Bytes read (in) and written (out) on protocol streams.",
+	}, "direction")
+	peerLatency = newHistogramVec(prometheus.HistogramOpts{
+		Name:    "p2p_peer_latency_seconds",
+		Help:    "Round-trip times sampled by pinging connected peers.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+)
+
+// RegisteredMetricNames returns the names of all metrics the networking
+// package registers with the default Prometheus registerer, sorted. Every
+// metric is labelled by network. Metrics of optional features such as
+// latency sampling are registered whether or not a host enables them, they
+// just have no series until it does. libp2p's own metrics are not listed.
+func RegisteredMetricNames() []string {
+	names := slices.Clone(metricNames)
+	slices.Sort(names)
+
+	return names
+}
+
+// metricsRegisterer returns the registerer libp2p's own metrics go to. It
+// adds the network label, so that they line up with ours. libp2p counts
+// process-wide though, so hosts of different networks in one process report
+// the same libp2p totals, each under its own label. Any other libp2p host in
+// the process has to register through it as well, since libp2p panics on a
+// metric registered both with and without the label.
+func (n *Host) metricsRegisterer() prometheus.Registerer {
+	return prometheus.WrapRegistererWith(prometheus.Labels{networkLabel: n.config().NetworkName}, prometheus.DefaultRegisterer)
+}
+
+func newGaugeVec(opts prometheus.GaugeOpts, labels ...string) *prometheus.GaugeVec {
+	metricNames = append(metricNames, opts.Name)
+	return promauto.NewGaugeVec(opts, append([]string{networkLabel}, labels...))
+}
+
+func newCounterVec(opts prometheus.CounterOpts, labels ...string) *prometheus.CounterVec {
+	metricNames = append(metricNames, opts.Name)
+	return promauto.NewCounterVec(opts, append([]string{networkLabel}, labels...))
+}
+
+func newHistogramVec(opts prometheus.HistogramOpts, labels ...string) *prometheus.HistogramVec {
+	metricNames = append(metricNames, opts.Name)
+	return promauto.NewHistogramVec(opts, append([]string{networkLabel}, labels...))
+}
@@ -0,0 +1,96 @@
+package networking
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetricsLabeledByNetwork(t *testing.T) {
+	// Only routable addresses observed by a quorum of peers raise the
+	// mismatch, so the series must actually move for the labels to matter.
+	mainnet := NewHost(&config.Config{NetworkName: "mainnet", HostAddress: "93.184.216.34"})
+	testnet := NewHost(&config.Config{NetworkName: "testnet", HostAddress: "93.184.216.34"})
+
+	for i := range observedAddrQuorum {
+		pid := peer.ID(fmt.Sprintf("peer-%d", i))
+		mainnet.recordObservedAddr(pid, ma.StringCast("/ip4/185.199.108.1/tcp/4001"))
+		testnet.recordObservedAddr(pid, ma.StringCast("/ip4/93.184.216.34/tcp/4001"))
+	}
+
+	if gaugeValue(t, observedAddrMismatch.WithLabelValues("mainnet")) != 1 {
+		t.Error("expected mismatch on the mainnet series")
+	}
+	if gaugeValue(t, observedAddrMismatch.WithLabelValues("testnet")) != 0 {
+		t.Error("expected no mismatch on the testnet series")
+	}
+}
+
+func TestLibp2pMetricsLabeledByNetwork(t *testing.T) {
+	n := newTestHost(t, &config.Config{NetworkName: "libp2p-metrics"})
+	other := newTestHost(t, &config.Config{NetworkName: "libp2p-metrics"})
+	connectHosts(t, n, other)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(families, func(f *dto.MetricFamily) bool { return f.GetName() == "libp2p_swarm_connections_opened_total" })
+	if i < 0 {
+		t.Fatal("expected the swarm connection metric to be registered")
+	}
+	labelled := slices.ContainsFunc(families[i].GetMetric(), func(m *dto.Metric) bool {
+		return slices.ContainsFunc(m.GetLabel(), func(l *dto.LabelPair) bool {
+			return l.GetName() == networkLabel && l.GetValue() == "libp2p-metrics"
+		})
+	})
+	if !labelled {
+		t.Error("expected the swarm connections to be counted under the network label")
+	}
+}
+
+func TestRegisteredMetricNames(t *testing.T) {
+	names := RegisteredMetricNames()
+
+	want := []string{
+		"p2p_observed_address_mismatch",
+		"p2p_peer_events_dropped_total",
+		"p2p_peer_latency_seconds",
+		"p2p_stream_bytes_total",
+		"p2p_time_to_first_peer_seconds",
+		"p2p_transport_dials_total",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	// Once every metric has a series, the list is exactly what the default
+	// registry exports besides libp2p's metrics.
+	const network = "metric-names"
+	observedAddrMismatch.WithLabelValues(network).Set(0)
+	timeToFirstPeer.WithLabelValues(network).Set(1)
+	peerEventsDropped.WithLabelValues(network).Inc()
+	transportDials.WithLabelValues(network, "quic", "success").Inc()
+	streamBytes.WithLabelValues(network, "in").Add(1)
+	peerLatency.WithLabelValues(network).Observe(0.01)
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []string
+	for _, family := range families {
+		if strings.HasPrefix(family.GetName(), "p2p_") {
+			exported = append(exported, family.GetName())
+		}
+	}
+	if !slices.Equal(exported, names) {
+		t.Errorf("expected the registry to export %v, got %v", names, exported)
+	}
+}
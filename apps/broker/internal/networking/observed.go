@@ -0,0 +1,122 @@
+package networking
+
+import (
+	"net"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// ObservedAddresses returns the distinct addresses connected peers reported
+// observing us at through identify.
+func (n *Host) ObservedAddresses() []ma.Multiaddr {
+	n.observedLock.RLock()
+	defer n.observedLock.RUnlock()
+
+	seen := make(map[string]struct{})
+	addrs := make([]ma.Multiaddr, 0, len(n.observed))
+	for _, addr := range n.observed {
+		if _, ok := seen[addr.String()]; ok {
+			continue
+		}
+		seen[addr.String()] = struct{}{}
+		addrs = append(addrs, addr)
+	}
+
+	return addrs
+}
+
+// watchIdentify records the address each peer observed us at once identify
+// completes.
+func (n *Host) watchIdentify() error {
+	sub, err := n.host.EventBus().Subscribe(new(event.EvtPeerIdentificationCompleted))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case evt, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				identified := evt.(event.EvtPeerIdentificationCompleted)
+				if identified.ObservedAddr != nil && !n.isProbeConn(identified.Conn) {
+					n.recordObservedAddr(identified.Peer, identified.ObservedAddr)
+				}
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// observedAddrQuorum is how many peers must observe us at the same public IP
+// other than the configured host address before it counts as a mismatch, so
+// a single misbehaving or misconfigured peer can't raise it.
+const observedAddrQuorum = 3
+
+func (n *Host) recordObservedAddr(pid peer.ID, addr ma.Multiaddr) {
+	n.observedLock.Lock()
+	defer n.observedLock.Unlock()
+
+	n.observed[pid] = addr
+	n.updateObservedAddrMismatch()
+}
+
+// updateObservedAddrMismatch sets the mismatch gauge from the addresses all
+// connected peers observed. Private addresses are skipped, as peers on the
+// same network always see those, and so are addresses of the other IP family,
+// which dual-stack peers reach us over. observedLock must be held.
+func (n *Host) updateObservedAddrMismatch() {
+	cfg := n.config()
+	hostIP := net.ParseIP(cfg.HostAddress)
+	if hostIP == nil {
+		return
+	}
+	hostIsIPv4 := hostIP.To4() != nil
+
+	votes := make(map[string]int)
+	var mismatch string
+	for _, addr := range n.observed {
+		if !manet.IsPublicAddr(addr) {
+			continue
+		}
+		ip, err := manet.ToIP(addr)
+		if err != nil || ip.Equal(hostIP) || (ip.To4() != nil) != hostIsIPv4 {
+			continue
+		}
+		votes[ip.String()]++
+		if votes[ip.String()] >= observedAddrQuorum {
+			mismatch = ip.String()
+		}
+	}
+
+	if mismatch == "" {
+		n.observedMismatch = false
+		observedAddrMismatch.WithLabelValues(cfg.NetworkName).Set(0)
+		return
+	}
+	if !n.observedMismatch {
+		base.Log.Warn("Peers observed us at an address other than the configured host address",
+			"observed", mismatch, "peers", votes[mismatch], "hostAddress", cfg.HostAddress)
+	}
+	n.observedMismatch = true
+	observedAddrMismatch.WithLabelValues(cfg.NetworkName).Set(1)
+}
+
+// forgetObservedAddr drops the address observed by a peer that disconnected.
+func (n *Host) forgetObservedAddr(pid peer.ID) {
+	n.observedLock.Lock()
+	defer n.observedLock.Unlock()
+
+	delete(n.observed, pid)
+	n.updateObservedAddrMismatch()
+}
@@ -0,0 +1,105 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m.GetGauge().GetValue()
+}
+
+func TestObservedAddresses(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+	connectHosts(t, a, b)
+
+	// Identify runs on connect, so each side learns how the other saw it.
+	waitFor(t, func() bool { return len(a.ObservedAddresses()) == 1 })
+	waitFor(t, func() bool { return len(b.ObservedAddresses()) == 1 })
+
+	_ = a.host.Network().ClosePeer(b.host.ID())
+	waitFor(t, func() bool { return len(a.ObservedAddresses()) == 0 })
+}
+
+func TestObservedAddressMismatch(t *testing.T) {
+	// The addresses must be routable, as private and documentation ranges
+	// are ignored.
+	n := NewHost(&config.Config{NetworkName: "observed-test", HostAddress: "93.184.216.34"})
+	mismatch := func() float64 { return gaugeValue(t, observedAddrMismatch.WithLabelValues("observed-test")) }
+
+	n.recordObservedAddr(peer.ID("a"), ma.StringCast("/ip4/93.184.216.34/tcp/4001"))
+	if mismatch() != 0 {
+		t.Error("expected no mismatch for the configured address")
+	}
+
+	// Private addresses only tell us a peer is on the same network.
+	for _, pid := range []peer.ID{"b", "c", "d"} {
+		n.recordObservedAddr(pid, ma.StringCast("/ip4/192.168.1.10/tcp/4001"))
+	}
+	if mismatch() != 0 {
+		t.Error("expected private observed addresses to be ignored")
+	}
+
+	// A single peer is not enough to flag a mismatch.
+	n.recordObservedAddr(peer.ID("b"), ma.StringCast("/ip4/185.199.108.1/tcp/4001"))
+	if mismatch() != 0 {
+		t.Error("expected a single peer not to flag a mismatch")
+	}
+	if len(n.ObservedAddresses()) != 3 {
+		t.Errorf("expected a single observed address per peer, got %v", n.ObservedAddresses())
+	}
+
+	n.recordObservedAddr(peer.ID("c"), ma.StringCast("/ip4/185.199.108.1/tcp/4002"))
+	n.recordObservedAddr(peer.ID("d"), ma.StringCast("/ip4/185.199.108.1/tcp/4003"))
+	if mismatch() != 1 {
+		t.Error("expected mismatch once enough peers agree")
+	}
+
+	// The peer reporting last doesn't flip the gauge back on its own.
+	n.recordObservedAddr(peer.ID("e"), ma.StringCast("/ip4/93.184.216.34/tcp/4001"))
+	if mismatch() != 1 {
+		t.Error("expected the mismatch to stay while enough peers agree")
+	}
+
+	n.forgetObservedAddr(peer.ID("d"))
+	if mismatch() != 0 {
+		t.Error("expected the mismatch to clear once too few peers agree")
+	}
+}
+
+func TestObservedAddressMismatchIgnoresOtherFamily(t *testing.T) {
+	n := NewHost(&config.Config{NetworkName: "observed-dual-stack-test", HostAddress: "93.184.216.34"})
+	mismatch := func() float64 {
+		return gaugeValue(t, observedAddrMismatch.WithLabelValues("observed-dual-stack-test"))
+	}
+
+	// A dual-stack node is reached over IPv6 by some peers; that is not a
+	// mismatch with its IPv4 host address.
+	for _, pid := range []peer.ID{"a", "b", "c"} {
+		n.recordObservedAddr(pid, ma.StringCast("/ip6/2606:2800:220:1:248:1893:25c8:1946/tcp/4001"))
+	}
+	n.recordObservedAddr(peer.ID("d"), ma.StringCast("/ip4/93.184.216.34/tcp/4001"))
+	if mismatch() != 0 {
+		t.Error("expected IPv6 observations not to be compared with an IPv4 host address")
+	}
+
+	for _, pid := range []peer.ID{"e", "f", "g"} {
+		n.recordObservedAddr(pid, ma.StringCast("/ip4/185.199.108.1/tcp/4001"))
+	}
+	if mismatch() != 1 {
+		t.Error("expected enough IPv4 observations of another address to flag a mismatch")
+	}
+}
@@ -0,0 +1,221 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+var (
+	ErrPeerKeyMismatch      = errors.New("peer public key does not match the expected key")
+	ErrPeerAlreadyConnected = errors.New("peer is already connected")
+)
+
+// PeerConnectedAddr returns the remote address of the active connection to
+// a peer, including the transport in use. When there are several
+// connections the oldest one is reported.
+func (n *Host) PeerConnectedAddr(pid peer.ID) (ma.Multiaddr, bool) {
+	conn := n.oldestConn(pid)
+	if conn == nil {
+		return nil, false
+	}
+
+	return conn.RemoteMultiaddr(), true
+}
+
+// oldestConn returns the longest-lived connection to a peer, or nil.
+func (n *Host) oldestConn(pid peer.ID) network.Conn {
+	conns := n.host.Network().ConnsToPeer(pid)
+	if len(conns) == 0 {
+		return nil
+	}
+
+	oldest := conns[0]
+	for _, conn := range conns[1:] {
+		if conn.Stat().Opened.Before(oldest.Stat().Opened) {
+			oldest = conn
+		}
+	}
+
+	return oldest
+}
+
+// ConnectAndVerify connects to a peer and checks that the public key it
+// authenticated with is the expected one. On mismatch the peer is
+// disconnected and ErrPeerKeyMismatch is returned.
+func (n *Host) ConnectAndVerify(ctx context.Context, pi peer.AddrInfo, expectedPubKey crypto.PubKey) error {
+	if err := n.connectWithPeer(ctx, pi); err != nil {
+		return err
+	}
+
+	for _, conn := range n.host.Network().ConnsToPeer(pi.ID) {
+		remote := conn.RemotePublicKey()
+		if remote != nil && remote.Equals(expectedPubKey) {
+			continue
+		}
+
+		if err := n.host.Network().ClosePeer(pi.ID); err != nil {
+			return fmt.Errorf("%w, failed to disconnect: %w", ErrPeerKeyMismatch, err)
+		}
+		return ErrPeerKeyMismatch
+	}
+
+	return nil
+}
+
+// TestDialResult describes a successful diagnostic dial.
+type TestDialResult struct {
+	Peer          peer.ID
+	Transport     string
+	Security      string
+	HandshakeTime time.Duration
+}
+
+// TestDial probes whether addr, which must include the /p2p/ peer ID, can be
+// connected to. The probe connection is closed right away and left out of all
+// peer bookkeeping, and the peer's addresses are restored to what they were
+// before. Peers we are already connected to are not probed, so that a real
+// connection is never torn down.
+func (n *Host) TestDial(ctx context.Context, addr ma.Multiaddr) (TestDialResult, error) {
+	info, err := peer.AddrInfoFromP2pAddr(addr)
+	if err != nil {
+		return TestDialResult{}, fmt.Errorf("invalid address %s: %w", addr, err)
+	}
+	if n.host.Network().Connectedness(info.ID) == network.Connected {
+		return TestDialResult{}, ErrPeerAlreadyConnected
+	}
+
+	n.startProbe(info.ID)
+	defer n.endProbe(info.ID)
+	defer n.restorePeerstore(info.ID, n.host.Peerstore().Addrs(info.ID))
+
+	start := time.Now()
+	if err := n.host.Connect(network.WithForceDirectDial(ctx, "test dial"), *info); err != nil {
+		return TestDialResult{}, fmt.Errorf("test dial to %s failed: %w", addr, err)
+	}
+	elapsed := time.Since(start)
+
+	var probe network.Conn
+	for _, conn := range n.host.Network().ConnsToPeer(info.ID) {
+		if !n.isProbeConn(conn) {
+			continue
+		}
+		if probe == nil {
+			probe = conn
+		}
+		defer conn.Close()
+	}
+	if probe == nil {
+		return TestDialResult{}, fmt.Errorf("test dial to %s: connection closed before it could be inspected", addr)
+	}
+
+	return TestDialResult{
+		Peer:          info.ID,
+		Transport:     transportOf(probe.RemoteMultiaddr()),
+		Security:      securityOf(probe.ConnState()),
+		HandshakeTime: elapsed,
+	}, nil
+}
+
+// startProbe marks outbound connections to pid opened until endProbe as
+// diagnostic probes, so that they are left out of peer bookkeeping. Other
+// connections of the peer, such as an inbound one it opens meanwhile, are
+// tracked as usual.
+func (n *Host) startProbe(pid peer.ID) {
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	n.probes[pid]++
+}
+
+func (n *Host) endProbe(pid peer.ID) {
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	if n.probes[pid]--; n.probes[pid] <= 0 {
+		delete(n.probes, pid)
+	}
+}
+
+// startSelfProbe marks every connection from pid, the host probing our own
+// addresses in VerifyAdvertisedAddresses, as a probe until endSelfProbe.
+func (n *Host) startSelfProbe(pid peer.ID) {
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	n.selfProbes[pid] = struct{}{}
+}
+
+func (n *Host) endSelfProbe(pid peer.ID) {
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	delete(n.selfProbes, pid)
+}
+
+// claimProbeConn marks conn as a probe if a probe of its peer is in progress
+// and we dialed it, or it comes from our self-dial prober, and reports
+// whether it did.
+func (n *Host) claimProbeConn(conn network.Conn) bool {
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	_, self := n.selfProbes[conn.RemotePeer()]
+	if !self && (n.probes[conn.RemotePeer()] <= 0 || conn.Stat().Direction != network.DirOutbound) {
+		return false
+	}
+	n.probeConns[conn.ID()] = struct{}{}
+
+	return true
+}
+
+// releaseProbeConn forgets conn once it is closed and reports whether it was
+// a probe.
+func (n *Host) releaseProbeConn(conn network.Conn) bool {
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	_, ok := n.probeConns[conn.ID()]
+	delete(n.probeConns, conn.ID())
+
+	return ok
+}
+
+func (n *Host) isProbeConn(conn network.Conn) bool {
+	if conn == nil {
+		return false
+	}
+
+	n.probesLock.Lock()
+	defer n.probesLock.Unlock()
+
+	_, ok := n.probeConns[conn.ID()]
+
+	return ok
+}
+
+// restorePeerstore drops the addresses a probe added for pid, keeping the
+// ones it had before. A peer that was unknown before is forgotten entirely.
+func (n *Host) restorePeerstore(pid peer.ID, before []ma.Multiaddr) {
+	ps := n.host.Peerstore()
+	if len(before) == 0 {
+		ps.ClearAddrs(pid)
+		ps.RemovePeer(pid)
+		return
+	}
+
+	var added []ma.Multiaddr
+	for _, addr := range ps.Addrs(pid) {
+		if !slices.ContainsFunc(before, addr.Equal) {
+			added = append(added, addr)
+		}
+	}
+	ps.SetAddrs(pid, added, 0)
+}
@@ -0,0 +1,207 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestPeerConnectedAddr(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+
+	if _, ok := a.PeerConnectedAddr(b.host.ID()); ok {
+		t.Fatal("expected no address for a peer we are not connected to")
+	}
+
+	connectHosts(t, a, b)
+	addr, ok := a.PeerConnectedAddr(b.host.ID())
+	if !ok {
+		t.Fatal("expected an address for a connected peer")
+	}
+	if transportOf(addr) != "tcp" {
+		t.Errorf("expected a tcp connection, got %s", addr)
+	}
+
+	found := false
+	for _, listen := range b.host.Addrs() {
+		if listen.Equal(addr) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("connected address %s is not one of %v", addr, b.host.Addrs())
+	}
+}
+
+func TestConnectAndVerify(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+	impostor := newTestHost(t, &config.Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info := peer.AddrInfo{ID: b.host.ID(), Addrs: b.host.Addrs()}
+	if err := a.ConnectAndVerify(ctx, info, b.host.Peerstore().PubKey(b.host.ID())); err != nil {
+		t.Fatalf("expected matching key to verify, got %v", err)
+	}
+
+	info = peer.AddrInfo{ID: impostor.host.ID(), Addrs: impostor.host.Addrs()}
+	err := a.ConnectAndVerify(ctx, info, b.host.Peerstore().PubKey(b.host.ID()))
+	if !errors.Is(err, ErrPeerKeyMismatch) {
+		t.Fatalf("expected key mismatch, got %v", err)
+	}
+	if _, ok := a.PeerConnectedAddr(impostor.host.ID()); ok {
+		t.Error("expected connection to the impostor to be closed")
+	}
+}
+
+func TestTestDial(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	target := newTestHost(t, &config.Config{})
+
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: target.host.ID(), Addrs: target.host.Addrs()[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := n.TestDial(ctx, addrs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Peer != target.host.ID() || result.Transport != "tcp" || result.Security == "" || result.HandshakeTime <= 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 0 })
+
+	unreachable := unreachablePeer(t)
+	dead, err := peer.AddrInfoToP2pAddrs(&unreachable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.TestDial(ctx, dead[0]); err == nil {
+		t.Error("expected probing an unreachable address to fail")
+	}
+}
+
+func TestTestDialLeavesNoTrace(t *testing.T) {
+	var connects atomic.Int64
+	n := newTestHost(t, &config.Config{
+		OnPeerConnect: func(peer.ID, network.Direction) { connects.Add(1) },
+	})
+	target := newTestHost(t, &config.Config{})
+
+	// An address learned earlier, e.g. from the registry, must survive.
+	known := ma.StringCast("/ip4/198.51.100.7/tcp/4001")
+	n.host.Peerstore().AddAddr(target.host.ID(), known, peerstore.AddressTTL)
+
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: target.host.ID(), Addrs: target.host.Addrs()[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := n.TestDial(ctx, addrs[0]); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 0 })
+
+	if churn := n.SnapshotAndResetChurn(); churn != (ChurnSnapshot{}) {
+		t.Errorf("expected the probe not to count as churn, got %+v", churn)
+	}
+	if events := n.recentEvents(); len(events) != 0 {
+		t.Errorf("expected no peer events for the probe, got %v", events)
+	}
+	if _, ok := n.PeerUptimeRatio(target.host.ID()); ok {
+		t.Error("expected no uptime history for the probed peer")
+	}
+	if _, ok := n.TimeToFirstPeer(); ok {
+		t.Error("expected the probe not to count as the first peer")
+	}
+	if connects.Load() != 0 {
+		t.Error("expected OnPeerConnect not to fire for the probe")
+	}
+	if got := n.host.Peerstore().Addrs(target.host.ID()); len(got) != 1 || !got[0].Equal(known) {
+		t.Errorf("expected only the previously known address to remain, got %v", got)
+	}
+}
+
+func TestTestDialKeepsExistingConnection(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	target := newTestHost(t, &config.Config{})
+	connectHosts(t, n, target)
+
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: target.host.ID(), Addrs: target.host.Addrs()[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := n.TestDial(ctx, addrs[0]); !errors.Is(err, ErrPeerAlreadyConnected) {
+		t.Fatalf("expected ErrPeerAlreadyConnected, got %v", err)
+	}
+	if n.host.Network().Connectedness(target.host.ID()) != network.Connected {
+		t.Error("expected the existing connection to stay open")
+	}
+}
+
+func TestProbedPeerKeepsRealConnection(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	target := newTestHost(t, &config.Config{})
+	pid := target.host.ID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n.startProbe(pid)
+	// Dial a single address so that exactly one probe connection forms.
+	if err := n.host.Connect(ctx, loopbackInfo(t, target)); err != nil {
+		t.Fatal(err)
+	}
+	probes := n.host.Network().ConnsToPeer(pid)
+	if len(probes) != 1 || !n.isProbeConn(probes[0]) {
+		t.Fatalf("expected one probe connection, got %v", probes)
+	}
+
+	// The peer connects to us from another host while the probe is open;
+	// that connection is real.
+	twin, err := libp2p.New(
+		libp2p.Identity(target.host.Peerstore().PrivKey(pid)),
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.PrometheusRegisterer(target.metricsRegisterer()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = twin.Close() })
+	if err := twin.Connect(ctx, loopbackInfo(t, n)); err != nil {
+		t.Fatal(err)
+	}
+	n.endProbe(pid)
+	waitFor(t, func() bool { return n.isTracked(pid) })
+
+	// Closing the probe must not drop the peer while the real connection lives.
+	if err := probes[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return !n.isProbeConn(probes[0]) })
+	if !n.isTracked(pid) {
+		t.Fatal("expected the peer to stay tracked after the probe closed")
+	}
+
+	if err := n.host.Network().ClosePeer(pid); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return !n.isTracked(pid) })
+}
@@ -0,0 +1,92 @@
+package networking
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/net/proxy"
+)
+
+// transports returns the transports the host runs: libp2p's defaults, or TCP
+// alone dialing through the SOCKS5 proxy when one is configured. UDP cannot
+// be proxied over SOCKS5, so QUIC is left out rather than dialed directly.
+// TCP connections are upgraded within HandshakeTimeout either way.
+func (n *Host) transports() libp2p.Option {
+	cfg := n.config()
+	if cfg.SOCKS5Proxy == "" {
+		if cfg.HandshakeTimeout > 0 {
+			return n.handshakeTransports()
+		}
+		return libp2p.DefaultTransports
+	}
+	if cfg.QUICPort > 0 {
+		base.Log.Warn("QUIC is disabled while dialing through a SOCKS5 proxy", "quicPort", cfg.QUICPort)
+	}
+
+	return libp2p.Transport(n.newSOCKS5Transport)
+}
+
+// socks5Transport is the TCP transport dialing through SOCKS5Proxy. It also
+// dials /dns addresses and keeps the swarm from resolving them, so that the
+// hostname is handed to the proxy instead of being looked up locally, which
+// would leak every peer we dial to the local resolver.
+type socks5Transport struct {
+	*tcp.TcpTransport
+}
+
+func (n *Host) newSOCKS5Transport(upgrader transport.Upgrader, rcmgr network.ResourceManager) (*socks5Transport, error) {
+	tpt, err := tcp.NewTCPTransport(n.withHandshakeTimeout(upgrader), rcmgr, nil, tcp.WithDialerForAddr(n.socks5Dialer))
+	if err != nil {
+		return nil, err
+	}
+
+	return &socks5Transport{TcpTransport: tpt}, nil
+}
+
+func (t *socks5Transport) CanDial(addr ma.Multiaddr) bool {
+	return t.TcpTransport.CanDial(addr) || isDNSTCPAddr(addr)
+}
+
+// SkipResolve tells the swarm to hand addresses to us unresolved.
+func (t *socks5Transport) SkipResolve(context.Context, ma.Multiaddr) bool {
+	return true
+}
+
+// isDNSTCPAddr reports whether addr is a /dns, /dns4 or /dns6 name followed
+// by a TCP port.
+func isDNSTCPAddr(addr ma.Multiaddr) bool {
+	name, rest := ma.SplitFirst(addr)
+	if name == nil || rest == nil {
+		return false
+	}
+	switch name.Protocol().Code {
+	case ma.P_DNS, ma.P_DNS4, ma.P_DNS6:
+	default:
+		return false
+	}
+	port, rest := ma.SplitFirst(rest)
+
+	return port != nil && port.Protocol().Code == ma.P_TCP && rest == nil
+}
+
+// socks5Dialer dials every outbound TCP connection through SOCKS5Proxy. Host
+// names are passed to the proxy as they are, for it to resolve.
+func (n *Host) socks5Dialer(ma.Multiaddr) (tcp.ContextDialer, error) {
+	address := n.config().SOCKS5Proxy
+	dialer, err := proxy.SOCKS5("tcp", address, nil, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("socks5 proxy %s: %w", address, err)
+	}
+	contextDialer, ok := dialer.(tcp.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("socks5 proxy %s: dialer does not support contexts", address)
+	}
+
+	return contextDialer, nil
+}
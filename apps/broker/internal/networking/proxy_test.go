@@ -0,0 +1,173 @@
+package networking
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// fakeSOCKS5 is a SOCKS5 server without authentication that only supports
+// CONNECT, counting the connections it relays. Host names are looked up in
+// hosts rather than resolved.
+type fakeSOCKS5 struct {
+	listener net.Listener
+	hosts    map[string]string
+	relayed  atomic.Int64
+	resolved atomic.Int64
+}
+
+func newFakeSOCKS5(t *testing.T) *fakeSOCKS5 {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &fakeSOCKS5{listener: listener, hosts: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSOCKS5) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, method count and methods. We only offer "no auth".
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	// Request: version, CONNECT, reserved, address type, address and port.
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = s.hosts[string(name)]
+		s.resolved.Add(1)
+	default:
+		ip := make(net.IP, net.IPv4len)
+		if request[3] == 4 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = ip.String()
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	s.relayed.Add(1)
+
+	go func() {
+		_, _ = io.Copy(target, conn)
+		_ = target.Close()
+	}()
+	_, _ = io.Copy(conn, target)
+}
+
+func TestDialThroughSOCKS5Proxy(t *testing.T) {
+	socks := newFakeSOCKS5(t)
+	target := newTestHost(t, &config.Config{})
+	n := newTestHost(t, &config.Config{SOCKS5Proxy: socks.listener.Addr().String(), QUICPort: freeUDPPort(t)})
+
+	for _, addr := range n.host.Network().ListenAddresses() {
+		if transportOf(addr) == "quic" {
+			t.Errorf("expected QUIC to be disabled behind the proxy, listening on %s", addr)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.connectWithPeer(ctx, loopbackInfo(t, target)); err != nil {
+		t.Fatalf("failed to connect through the proxy: %v", err)
+	}
+	if socks.relayed.Load() == 0 {
+		t.Error("expected the dial to go through the proxy")
+	}
+}
+
+func TestDialThroughSOCKS5ProxyLeavesDNSToProxy(t *testing.T) {
+	socks := newFakeSOCKS5(t)
+	// The .invalid TLD never resolves, so the dial only works if the name
+	// reaches the proxy unresolved.
+	socks.hosts["broker.invalid"] = "127.0.0.1"
+	target := newTestHost(t, &config.Config{})
+	n := newTestHost(t, &config.Config{SOCKS5Proxy: socks.listener.Addr().String()})
+
+	port, err := loopbackInfo(t, target).Addrs[0].ValueForProtocol(ma.P_TCP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ma.StringCast("/dns4/broker.invalid/tcp/" + port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.connectWithPeer(ctx, peer.AddrInfo{ID: target.host.ID(), Addrs: []ma.Multiaddr{addr}}); err != nil {
+		t.Fatalf("failed to connect through the proxy: %v", err)
+	}
+	if socks.resolved.Load() == 0 {
+		t.Error("expected the proxy to resolve the host name")
+	}
+}
+
+func TestDialFailsWhenSOCKS5ProxyIsDown(t *testing.T) {
+	socks := newFakeSOCKS5(t)
+	_ = socks.listener.Close()
+	target := newTestHost(t, &config.Config{})
+	n := newTestHost(t, &config.Config{SOCKS5Proxy: socks.listener.Addr().String()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.connectWithPeer(ctx, loopbackInfo(t, target)); err == nil {
+		t.Fatal("expected dialing to fail without a working proxy")
+	}
+}
@@ -0,0 +1,100 @@
+package networking
+
+import (
+	"fmt"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// IsPubliclyReachable reports whether the node appears dialable from the
+// public internet, along with the reason. It is based on AutoNAT
+// reachability and, while that is unknown, on the addresses peers observe us
+// at.
+func (n *Host) IsPubliclyReachable() (bool, string) {
+	switch n.currentReachability() {
+	case network.ReachabilityPublic:
+		return true, "AutoNAT reports the node as publicly reachable"
+	case network.ReachabilityPrivate:
+		return false, "AutoNAT reports the node as not publicly reachable; check port forwarding and the configured host address"
+	}
+
+	for _, addr := range n.ObservedAddresses() {
+		if manet.IsPublicAddr(addr) {
+			return false, fmt.Sprintf("reachability unknown; peers observe us at %s but dialing back has not been confirmed", addr)
+		}
+	}
+
+	return false, "reachability unknown and no peer observed a public address"
+}
+
+func (n *Host) currentReachability() network.Reachability {
+	n.reachabilityLock.RLock()
+	defer n.reachabilityLock.RUnlock()
+
+	return n.reachability
+}
+
+// OnReachabilityChanged registers fn to be called whenever AutoNAT reports a
+// reachability transition. Callbacks run on the event goroutine and are
+// dropped once GracefulStop is called.
+func (n *Host) OnReachabilityChanged(fn func(network.Reachability)) {
+	n.reachabilityLock.Lock()
+	defer n.reachabilityLock.Unlock()
+
+	n.reachabilityCallbacks = append(n.reachabilityCallbacks, fn)
+}
+
+// setReachability stores r and notifies the callbacks if it differs from
+// the previous value.
+func (n *Host) setReachability(r network.Reachability) {
+	n.reachabilityLock.Lock()
+	changed := n.reachability != r
+	n.reachability = r
+	callbacks := n.reachabilityCallbacks
+	n.reachabilityLock.Unlock()
+
+	if !changed {
+		return
+	}
+	for _, fn := range callbacks {
+		fn(r)
+	}
+}
+
+// detachReachabilityCallbacks drops all registered callbacks.
+func (n *Host) detachReachabilityCallbacks() {
+	n.reachabilityLock.Lock()
+	defer n.reachabilityLock.Unlock()
+
+	n.reachabilityCallbacks = nil
+}
+
+// watchReachability tracks AutoNAT reachability changes.
+func (n *Host) watchReachability() error {
+	sub, err := n.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case evt, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				reachability := evt.(event.EvtLocalReachabilityChanged).Reachability
+				base.Log.Info("Local reachability changed", "reachability", reachability)
+				n.setReachability(reachability)
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
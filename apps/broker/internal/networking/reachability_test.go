@@ -0,0 +1,88 @@
+package networking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestIsPubliclyReachable(t *testing.T) {
+	n := NewHost(&config.Config{})
+
+	if ok, reason := n.IsPubliclyReachable(); ok || reason == "" {
+		t.Fatalf("expected unknown reachability to be reported as unreachable, got %v %q", ok, reason)
+	}
+
+	n.recordObservedAddr(peer.ID("a"), ma.StringCast("/ip4/8.8.8.8/tcp/4001"))
+	if ok, _ := n.IsPubliclyReachable(); ok {
+		t.Error("expected an unconfirmed observed address not to count as reachable")
+	}
+
+	n.setReachability(network.ReachabilityPublic)
+	if ok, _ := n.IsPubliclyReachable(); !ok {
+		t.Error("expected public reachability to be reported")
+	}
+
+	n.setReachability(network.ReachabilityPrivate)
+	if ok, _ := n.IsPubliclyReachable(); ok {
+		t.Error("expected private reachability to be reported as unreachable")
+	}
+}
+
+func TestOnReachabilityChanged(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+
+	changes := make(chan network.Reachability, 4)
+	n.OnReachabilityChanged(func(r network.Reachability) { changes <- r })
+
+	emitter, err := n.host.EventBus().Emitter(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emitter.Close()
+	emit := func(r network.Reachability) {
+		if err := emitter.Emit(event.EvtLocalReachabilityChanged{Reachability: r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := func() network.Reachability {
+		select {
+		case r := <-changes:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback did not fire")
+			return network.ReachabilityUnknown
+		}
+	}
+
+	emit(network.ReachabilityPrivate)
+	if r := next(); r != network.ReachabilityPrivate {
+		t.Errorf("expected private reachability, got %s", r)
+	}
+
+	// Repeating the same status is not a transition.
+	emit(network.ReachabilityPrivate)
+	emit(network.ReachabilityPublic)
+	if r := next(); r != network.ReachabilityPublic {
+		t.Errorf("expected only the transition to public, got %s", r)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.GracefulStop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	n.setReachability(network.ReachabilityPrivate)
+	select {
+	case r := <-changes:
+		t.Errorf("expected callbacks to be detached after stop, got %s", r)
+	default:
+	}
+}
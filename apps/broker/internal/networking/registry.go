@@ -0,0 +1,109 @@
+package networking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// registryFetchTimeout bounds a single request to the peer registry.
+	registryFetchTimeout = 30 * time.Second
+	// registryMaxBackoff caps the wait between failed registry fetches.
+	registryMaxBackoff = 10 * time.Minute
+)
+
+// watchRegistry periodically fetches peers from RegistryURL and dials the
+// ones we are not connected to. Failed fetches back off exponentially.
+// RestartDiscovery triggers a fetch right away.
+func (n *Host) watchRegistry() {
+	defer n.registryRunning.Store(false)
+
+	cfg := n.config()
+	interval := cfg.RegistryRefreshInterval
+	wait, backoff := time.Duration(0), time.Duration(0)
+	for {
+		select {
+		case <-time.After(wait):
+		case <-n.registryRefresh:
+		case <-n.ctx.Done():
+			return
+		}
+
+		addrs, err := n.fetchRegistry()
+		if err != nil {
+			backoff = registryBackoff(backoff, interval)
+			wait = backoff
+			base.Log.Warn("Failed to fetch peer registry", "url", cfg.RegistryURL, "retryIn", wait, "error", err)
+			continue
+		}
+		wait, backoff = interval, 0
+		n.dialRegistryPeers(addrs)
+	}
+}
+
+// registryBackoff returns the wait after a failed fetch given the previous
+// one, zero after a success. It starts at the poll interval and doubles up to
+// registryMaxBackoff, so a failing registry is never polled more often than a
+// healthy one.
+func registryBackoff(prev, interval time.Duration) time.Duration {
+	if prev == 0 {
+		return interval
+	}
+
+	return max(min(2*prev, registryMaxBackoff), interval)
+}
+
+// fetchRegistry downloads the registry, a JSON array of peer multiaddrs that
+// include the /p2p/ peer ID.
+func (n *Host) fetchRegistry() ([]string, error) {
+	ctx, cancel := context.WithTimeout(n.ctx, registryFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.config().RegistryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var addrs []string
+	if err := json.NewDecoder(resp.Body).Decode(&addrs); err != nil {
+		return nil, fmt.Errorf("failed to decode registry: %w", err)
+	}
+
+	return addrs, nil
+}
+
+// dialRegistryPeers dials the registry's peers and waits for the dials to
+// finish, so a slow round delays the next fetch.
+func (n *Host) dialRegistryPeers(addrs []string) {
+	queue := make(chan peer.AddrInfo)
+	go func() {
+		defer close(queue)
+		for _, addr := range addrs {
+			info, err := peer.AddrInfoFromString(addr)
+			if err != nil {
+				base.Log.Debug("Skipping invalid registry entry", "addr", addr, "error", err)
+				continue
+			}
+			select {
+			case queue <- *info:
+			case <-n.ctx.Done():
+				return
+			}
+		}
+	}()
+	n.dialDiscoveredPeers(n.ctx, queue)
+}
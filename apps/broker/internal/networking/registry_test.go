@@ -0,0 +1,59 @@
+package networking
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRegistryPeersAreDialed(t *testing.T) {
+	registered := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: registered.host.ID(), Addrs: registered.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests atomic.Int64
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Fail the first fetch to exercise the retry.
+		if requests.Add(1) == 1 {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]string{addrs[0].String(), "garbage"})
+	}))
+	defer registry.Close()
+
+	n := newTestHost(t, &config.Config{
+		RegistryURL:             registry.URL,
+		RegistryRefreshInterval: 50 * time.Millisecond,
+	})
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+	if requests.Load() < 2 {
+		t.Errorf("expected the registry to be fetched again after a failure, got %d requests", requests.Load())
+	}
+}
+
+func TestRegistryBackoff(t *testing.T) {
+	tests := []struct {
+		prev, interval, want time.Duration
+	}{
+		{0, time.Minute, time.Minute},
+		{time.Minute, time.Minute, 2 * time.Minute},
+		{8 * time.Minute, time.Minute, registryMaxBackoff},
+		{registryMaxBackoff, time.Minute, registryMaxBackoff},
+		{0, time.Hour, time.Hour},
+		{time.Hour, time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := registryBackoff(tt.prev, tt.interval); got != tt.want {
+			t.Errorf("registryBackoff(%v, %v) = %v, want %v", tt.prev, tt.interval, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package networking
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+)
+
+var ErrRestartRequired = errors.New("config changes require a restart")
+
+// reloadableFields are the config fields ReloadConfig applies to a running
+// host. They are all read through config whenever they are needed, so a new
+// value takes effect from the next connection, stream or tick.
+var reloadableFields = map[string]bool{
+	"MaxInboundPeers":              true,
+	"MaxOutboundPeers":             true,
+	"MaxPeerCount":                 true,
+	"Bootnodes":                    true,
+	"StaticPeers":                  true,
+	"IPFamilyPreference":           true,
+	"DuplicateConnectionPolicy":    true,
+	"ProtocolDeadlines":            true,
+	"AsymmetryWindow":              true,
+	"VerboseBelowPeers":            true,
+	"BlocklistPath":                true,
+	"AddrFilter":                   true,
+	"AddrFilterOverridesBlocklist": true,
+	"ASNResolver":                  true,
+	"MaxPeersPerASN":               true,
+	"PeerEventDumpPath":            true,
+	"IdlePeerTimeout":              true,
+	"IsolationTimeout":             true,
+	"LatencySampleInterval":        true,
+	"MaxIngressBytesPerSec":        true,
+	"MaxEgressBytesPerSec":         true,
+	"MaxInboundHandshakeRate":      true,
+	"MaxInboundHandshakeBurst":     true,
+	"InboundAdmission":             true,
+	"OnPeerConnect":                true,
+	"OnPeerDisconnect":             true,
+}
+
+// ReloadConfig applies the settings in cfg that can change on a running host:
+// peer limits, bootnodes, static peers, the blocklist, filters, hooks,
+// protocol deadlines, the idle, isolation and latency intervals, bandwidth
+// and handshake rate limits, and logging. Newly configured bootnodes and
+// static peers are dialed, and removed ones lose their protection from idle
+// disconnects. Every other changed field is left untouched, logged
+// and reported in an error wrapping ErrRestartRequired. That includes
+// AddrDialTimeout, which is built into the swarm, and the registry refresh
+// interval.
+func (n *Host) ReloadConfig(cfg *config.Config) error {
+	n.reloadLock.Lock()
+	defer n.reloadLock.Unlock()
+
+	cur := n.config()
+	next := *cur
+
+	var restart []string
+	nextValue, newValue, curValue := reflect.ValueOf(&next).Elem(), reflect.ValueOf(cfg).Elem(), reflect.ValueOf(cur).Elem()
+	for i := range newValue.NumField() {
+		field := newValue.Type().Field(i).Name
+		if !fieldChanged(curValue.Field(i), newValue.Field(i)) {
+			continue
+		}
+		if !reloadableFields[field] {
+			restart = append(restart, field)
+			continue
+		}
+		nextValue.Field(i).Set(newValue.Field(i))
+	}
+	n.cfg.Store(&next)
+	if next.MaxIngressBytesPerSec != cur.MaxIngressBytesPerSec {
+		setByteLimit(n.ingress, next.MaxIngressBytesPerSec)
+	}
+	if next.MaxEgressBytesPerSec != cur.MaxEgressBytesPerSec {
+		setByteLimit(n.egress, next.MaxEgressBytesPerSec)
+	}
+	if next.MaxInboundHandshakeRate != cur.MaxInboundHandshakeRate || next.MaxInboundHandshakeBurst != cur.MaxInboundHandshakeBurst {
+		setHandshakeLimit(n.handshakes, next.MaxInboundHandshakeRate, next.MaxInboundHandshakeBurst)
+	}
+	reloaded := make(chan struct{})
+	close(*n.reloaded.Swap(&reloaded))
+
+	if next.BlocklistPath != cur.BlocklistPath {
+		n.reloadBlocklist()
+		if cur.BlocklistPath == "" && next.BlocklistPath != "" {
+			n.watchBlocklist()
+		}
+	}
+	if n.host != nil {
+		n.updateLogVerbosity()
+		if !slices.Equal(next.Bootnodes, cur.Bootnodes) {
+			go n.connectToBootnodes()
+		}
+		if added := addedEntries(cur.StaticPeers, next.StaticPeers); len(added) > 0 {
+			go n.connectToStaticPeers(added)
+		}
+		n.unprotectRemovedPeers(cur.Bootnodes, next.Bootnodes, bootnodeTag)
+		n.unprotectRemovedPeers(cur.StaticPeers, next.StaticPeers, staticPeerTag)
+	}
+
+	if len(restart) > 0 {
+		base.Log.Warn("Config reloaded partially, some changes require a restart", "requiresRestart", restart)
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(restart, ", "))
+	}
+	base.Log.Info("Config reloaded")
+
+	return nil
+}
+
+// fieldChanged reports whether a config field differs between two configs.
+// Funcs can't be compared, so they count as changed unless both are nil or
+// they are the same function.
+func fieldChanged(before, after reflect.Value) bool {
+	if before.Kind() == reflect.Func {
+		if before.IsNil() || after.IsNil() {
+			return before.IsNil() != after.IsNil()
+		}
+		return before.Pointer() != after.Pointer()
+	}
+
+	return !reflect.DeepEqual(before.Interface(), after.Interface())
+}
+
+// addedEntries returns the entries of next that are not in prev.
+func addedEntries(prev, next []string) []string {
+	var added []string
+	for _, entry := range next {
+		if !slices.Contains(prev, entry) {
+			added = append(added, entry)
+		}
+	}
+
+	return added
+}
@@ -0,0 +1,261 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+)
+
+func TestReloadConfigAppliesInPlaceChanges(t *testing.T) {
+	n := NewHost(&config.Config{TCPPort: 4001})
+
+	_, pub, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	banned, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte(banned.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.ReloadConfig(&config.Config{
+		TCPPort:         4001,
+		MaxInboundPeers: 5,
+		MaxPeerCount:    10,
+		Bootnodes:       []string{"/ip4/203.0.113.1/tcp/4001/p2p/12D3KooWGC6TvWhfapngX6wvJHMYvKpDMXPb3ZnCZ6dMoaMtimQ5"},
+		BlocklistPath:   path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.config().MaxInboundPeers != 5 || n.config().MaxPeerCount != 10 || len(n.config().Bootnodes) != 1 {
+		t.Errorf("expected limits and bootnodes to be applied, got %+v", n.config())
+	}
+	if !n.isBlockedPeer(banned) {
+		t.Error("expected the new blocklist to be loaded")
+	}
+}
+
+func TestReloadConfigReportsRestartRequired(t *testing.T) {
+	n := NewHost(&config.Config{TCPPort: 4001, QUICPort: 4002})
+
+	err := n.ReloadConfig(&config.Config{TCPPort: 5001, QUICPort: 4002, MaxOutboundPeers: 3})
+	if !errors.Is(err, ErrRestartRequired) {
+		t.Fatalf("expected ErrRestartRequired, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "TCPPort") || strings.Contains(err.Error(), "QUICPort") {
+		t.Errorf("expected only TCPPort to be reported, got %q", err)
+	}
+	if n.config().TCPPort != 4001 {
+		t.Error("expected the listen port to be left unchanged")
+	}
+	if n.config().MaxOutboundPeers != 3 {
+		t.Error("expected in-place changes to be applied alongside restart-required ones")
+	}
+}
+
+func TestReloadConfigReportsEveryUnappliedField(t *testing.T) {
+	n := NewHost(&config.Config{})
+
+	err := n.ReloadConfig(&config.Config{
+		KeyMissingBehavior: config.KeyFail,
+		DiscoveryBackend:   &fakeDiscovery{},
+		ProtocolDeadlines:  map[string]config.ProtocolDeadline{testSlowProtocol: {Read: time.Minute}},
+		AsymmetryWindow:    time.Hour,
+	})
+	if !errors.Is(err, ErrRestartRequired) {
+		t.Fatalf("expected ErrRestartRequired, got %v", err)
+	}
+	for _, field := range []string{"KeyMissingBehavior", "DiscoveryBackend"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s to be reported, got %q", field, err)
+		}
+	}
+	if n.config().KeyMissingBehavior != "" || n.config().DiscoveryBackend != nil {
+		t.Error("expected restart-only fields to be left unchanged")
+	}
+	if read, _ := chunkDeadlines(n.withDeadlines(nil, testSlowProtocol)); read != time.Minute {
+		t.Errorf("expected the new protocol deadline to apply, got %v", read)
+	}
+	if n.config().AsymmetryWindow != time.Hour {
+		t.Error("expected the asymmetry window to be applied")
+	}
+}
+
+func TestReloadConfigReportsTimeouts(t *testing.T) {
+	n := NewHost(&config.Config{})
+
+	err := n.ReloadConfig(&config.Config{
+		AddrDialTimeout:         time.Second,
+		RegistryRefreshInterval: time.Minute,
+		IdlePeerTimeout:         time.Minute,
+		IsolationTimeout:        time.Minute,
+		LatencySampleInterval:   time.Minute,
+	})
+	if !errors.Is(err, ErrRestartRequired) {
+		t.Fatalf("expected ErrRestartRequired, got %v", err)
+	}
+	for _, field := range []string{"AddrDialTimeout", "RegistryRefreshInterval"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s to be reported, got %q", field, err)
+		}
+	}
+	for _, field := range []string{"IdlePeerTimeout", "IsolationTimeout", "LatencySampleInterval"} {
+		if strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s to be applied in place, got %q", field, err)
+		}
+	}
+	if n.config().AddrDialTimeout != 0 {
+		t.Error("expected the dial timeout fixed at startup to be left unchanged")
+	}
+	if n.config().IdlePeerTimeout != time.Minute {
+		t.Error("expected the idle timeout to be applied")
+	}
+}
+
+func TestReloadConfigEnablesIdleTimeout(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+	connectHosts(t, client, server)
+
+	if err := server.ReloadConfig(&config.Config{IdlePeerTimeout: 200 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return server.ConnectedPeerCount() == 0 })
+}
+
+func TestReloadConfigEnablesLatencySampling(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	other := newTestHost(t, &config.Config{})
+	connectHosts(t, n, other)
+
+	if err := n.ReloadConfig(&config.Config{LatencySampleInterval: 20 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, _, _, ok := n.PeerLatencyPercentiles(other.host.ID())
+		return ok
+	})
+}
+
+func TestReloadConfigEnablesIsolationWatchdog(t *testing.T) {
+	bootnode := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: bootnode.host.ID(), Addrs: bootnode.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Bootnodes: []string{addrs[0].String()}}
+	n := newTestHost(t, cfg)
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+	_ = n.host.Network().ClosePeer(bootnode.host.ID())
+
+	next := *cfg
+	next.IsolationTimeout = 200 * time.Millisecond
+	if err := n.ReloadConfig(&next); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.IsolationRestarts() > 0 })
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+}
+
+func TestReloadConfigUpdatesRateLimits(t *testing.T) {
+	n := NewHost(&config.Config{MaxEgressBytesPerSec: 1 << 10})
+
+	err := n.ReloadConfig(&config.Config{
+		MaxIngressBytesPerSec:    2 << 10,
+		MaxInboundHandshakeRate:  5,
+		MaxInboundHandshakeBurst: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.ingress.Limit() != 2<<10 || n.ingress.Burst() != 2<<10 {
+		t.Errorf("expected the ingress limit to be applied, got %v/%d", n.ingress.Limit(), n.ingress.Burst())
+	}
+	if n.egress.Limit() != rate.Inf {
+		t.Errorf("expected the egress limit to be lifted, got %v", n.egress.Limit())
+	}
+	if n.handshakes.Limit() != 5 || n.handshakes.Burst() != 10 {
+		t.Errorf("expected the handshake limit to be applied, got %v/%d", n.handshakes.Limit(), n.handshakes.Burst())
+	}
+
+	if err := n.ReloadConfig(&config.Config{MaxEgressBytesPerSec: 4 << 10}); err != nil {
+		t.Fatal(err)
+	}
+	if n.ingress.Limit() != rate.Inf || n.handshakes.Limit() != rate.Inf {
+		t.Error("expected the ingress and handshake limits to be lifted")
+	}
+	if n.egress.Limit() != 4<<10 || n.egress.Burst() != 4<<10 {
+		t.Errorf("expected the egress limit to be applied, got %v/%d", n.egress.Limit(), n.egress.Burst())
+	}
+}
+
+func TestReloadedEgressLimitThrottlesOpenStreams(t *testing.T) {
+	const limit = 64 << 10
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+
+	received := make(chan int64, 1)
+	server.setStreamHandler("/test/throttle/1.0.0", func(s network.Stream) {
+		defer s.Close()
+		copied, _ := io.Copy(io.Discard, s)
+		received <- copied
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := client.host.NewStream(ctx, server.host.ID(), "/test/throttle/1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := client.throttle(s)
+
+	if err := client.ReloadConfig(&config.Config{MaxEgressBytesPerSec: limit}); err != nil {
+		t.Fatal(err)
+	}
+	// The bucket starts out empty, so this takes about two seconds.
+	start := time.Now()
+	if _, err := stream.Write(make([]byte, 2*limit)); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	_ = stream.CloseWrite()
+
+	if elapsed < 1500*time.Millisecond || elapsed > 4*time.Second {
+		t.Errorf("expected writing 2s worth of data to take about 2s, took %s", elapsed)
+	}
+	if got := <-received; got != 2*limit {
+		t.Errorf("expected all %d bytes to arrive, got %d", 2*limit, got)
+	}
+}
+
+func TestReloadConfigDialsNewStaticPeers(t *testing.T) {
+	n := newTestHost(t, &config.Config{})
+	static := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: static.host.ID(), Addrs: static.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.ReloadConfig(&config.Config{StaticPeers: []string{addrs[0].String()}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.host.Network().Connectedness(static.host.ID()) == network.Connected })
+}
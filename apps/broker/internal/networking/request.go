@@ -0,0 +1,162 @@
+package networking
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+var ErrEmptyResponse = errors.New("peer sent no response")
+
+// RequestPriority orders requests waiting for a free slot on a protocol.
+// Waiting requests of a higher priority are always sent first.
+type RequestPriority int
+
+const (
+	PriorityLow RequestPriority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// requestLimiter bounds the number of concurrent outbound requests per
+// protocol, handing freed slots to the highest priority waiter.
+type requestLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[protocol.ID]*protocolSlots
+}
+
+// protocolSlots holds the requests in flight for a protocol and the ones
+// waiting for a slot, oldest first per priority.
+type protocolSlots struct {
+	active  int
+	waiting [PriorityHigh + 1][]chan struct{}
+}
+
+func newRequestLimiter(limit int) *requestLimiter {
+	return &requestLimiter{
+		limit: limit,
+		slots: make(map[protocol.ID]*protocolSlots),
+	}
+}
+
+// acquire blocks until a request slot for the protocol is free and returns a
+// function releasing it.
+func (l *requestLimiter) acquire(ctx context.Context, pid protocol.ID, priority RequestPriority) (func(), error) {
+	if l.limit <= 0 {
+		return func() {}, nil
+	}
+	priority = min(max(priority, PriorityLow), PriorityHigh)
+
+	l.mu.Lock()
+	slots, ok := l.slots[pid]
+	if !ok {
+		slots = &protocolSlots{}
+		l.slots[pid] = slots
+	}
+	if slots.active < l.limit {
+		slots.active++
+		l.mu.Unlock()
+		return func() { l.release(slots) }, nil
+	}
+	ready := make(chan struct{})
+	slots.waiting[priority] = append(slots.waiting[priority], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return func() { l.release(slots) }, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		queue := slots.waiting[priority]
+		if i := slices.Index(queue, ready); i >= 0 {
+			slots.waiting[priority] = slices.Delete(queue, i, i+1)
+			l.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		l.mu.Unlock()
+		// The slot was handed to us as we gave up, pass it on.
+		l.release(slots)
+		return nil, ctx.Err()
+	}
+}
+
+// release hands the slot to the highest priority waiter, or frees it when
+// nobody is waiting.
+func (l *requestLimiter) release(slots *protocolSlots) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for priority := PriorityHigh; priority >= PriorityLow; priority-- {
+		if queue := slots.waiting[priority]; len(queue) > 0 {
+			close(queue[0])
+			slots.waiting[priority] = queue[1:]
+			return
+		}
+	}
+	slots.active--
+}
+
+// Request sends req to a peer on the given protocol and decodes the single
+// chunk the peer responds with into resp. Requests are limited per protocol
+// by MaxConcurrentRequestsPerProtocol.
+func (n *Host) Request(ctx context.Context, pid peer.ID, protocolID protocol.ID, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler) error {
+	return n.RequestWithPriority(ctx, pid, protocolID, PriorityNormal, req, resp)
+}
+
+// RequestWithPriority is Request with an explicit priority. When the protocol
+// is at its concurrency limit, higher priority requests are sent before lower
+// priority ones that have been waiting longer.
+func (n *Host) RequestWithPriority(ctx context.Context, pid peer.ID, protocolID protocol.ID, priority RequestPriority, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler) error {
+	release, err := n.requests.acquire(ctx, protocolID, priority)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	s, err := n.host.NewStream(ctx, pid, protocolID)
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	stream := n.withDeadlines(n.throttle(s), protocolID)
+	defer stream.Close()
+	n.markActive(pid)
+	defer n.markActive(pid)
+
+	if err := WriteChunks(stream, []encoding.BinaryMarshaler{req}); err != nil {
+		_ = stream.Reset()
+		return err
+	}
+	if err := stream.CloseWrite(); err != nil {
+		_ = stream.Reset()
+		return err
+	}
+
+	received := 0
+	err = ReadChunks(stream,
+		func() encoding.BinaryUnmarshaler { return resp },
+		func(encoding.BinaryUnmarshaler) error {
+			received++
+			if received > 1 {
+				return errors.New("peer sent more than one response chunk")
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		_ = stream.Reset()
+		return err
+	}
+	if received == 0 {
+		return ErrEmptyResponse
+	}
+
+	return nil
+}
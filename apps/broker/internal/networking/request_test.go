@@ -0,0 +1,171 @@
+package networking
+
+import (
+	"context"
+	"encoding"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+const testRequestProtocol = "/test/request/1.0.0"
+
+// echoHandler answers each request with the request itself after delay.
+func echoHandler(t *testing.T, delay time.Duration, active, maxActive *atomic.Int64) network.StreamHandler {
+	return func(s network.Stream) {
+		defer s.Close()
+
+		if active != nil {
+			current := active.Add(1)
+			defer active.Add(-1)
+			for {
+				highest := maxActive.Load()
+				if current <= highest || maxActive.CompareAndSwap(highest, current) {
+					break
+				}
+			}
+		}
+
+		var req testItem
+		err := ReadChunks(s,
+			func() encoding.BinaryUnmarshaler { return &req },
+			func(encoding.BinaryUnmarshaler) error { return nil },
+		)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		time.Sleep(delay)
+		if err := WriteChunks(s, []encoding.BinaryMarshaler{&req}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRequest(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+	server.setStreamHandler(testRequestProtocol, echoHandler(t, 0, nil, nil))
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var resp testItem
+	if err := client.Request(ctx, server.host.ID(), testRequestProtocol, &testItem{"ping"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.value != "ping" {
+		t.Errorf("unexpected response %q", resp.value)
+	}
+}
+
+func TestRequestConcurrencyLimit(t *testing.T) {
+	const limit = 2
+
+	var active, maxActive atomic.Int64
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{MaxConcurrentRequestsPerProtocol: limit})
+	server.setStreamHandler(testRequestProtocol, echoHandler(t, 100*time.Millisecond, &active, &maxActive))
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var resp testItem
+			if err := client.Request(ctx, server.host.ID(), testRequestProtocol, &testItem{"ping"}, &resp); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxActive.Load(); got > limit {
+		t.Errorf("expected at most %d concurrent requests, got %d", limit, got)
+	}
+}
+
+func TestRequestLimiterPriority(t *testing.T) {
+	l := newRequestLimiter(1)
+	ctx := context.Background()
+
+	queued := func() int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		total := 0
+		for _, queue := range l.slots[testRequestProtocol].waiting {
+			total += len(queue)
+		}
+		return total
+	}
+
+	release, err := l.acquire(ctx, testRequestProtocol, PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []RequestPriority
+		wg    sync.WaitGroup
+	)
+	send := func(priority RequestPriority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := l.acquire(ctx, testRequestProtocol, priority)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+			done()
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		send(PriorityLow)
+	}
+	waitFor(t, func() bool { return queued() == 3 })
+	send(PriorityHigh)
+	waitFor(t, func() bool { return queued() == 4 })
+
+	release()
+	wg.Wait()
+	if len(order) != 4 || order[0] != PriorityHigh {
+		t.Errorf("expected the high priority request to be sent first, got %v", order)
+	}
+}
+
+func TestRequestLimiterCancelledWaiter(t *testing.T) {
+	l := newRequestLimiter(1)
+
+	release, err := l.acquire(context.Background(), testRequestProtocol, PriorityNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, testRequestProtocol, PriorityHigh); err == nil {
+		t.Fatal("expected the waiter to give up once its context is done")
+	}
+	release()
+
+	// The slot is free again rather than held by the cancelled waiter.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := l.acquire(ctx, testRequestProtocol, PriorityLow); err != nil {
+		t.Fatalf("expected the freed slot to be available: %v", err)
+	}
+}
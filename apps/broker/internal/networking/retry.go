@@ -0,0 +1,68 @@
+package networking
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"time"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// RetryPolicy controls how RequestWithRetry retries failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// Backoff is the wait before the first retry. It doubles for every
+	// further retry.
+	Backoff time.Duration
+	// Retryable decides whether an error is worth retrying. When nil,
+	// IsRetryableRequestError is used.
+	Retryable func(error) bool
+}
+
+// IsRetryableRequestError reports whether a request failed for a transient
+// reason such as a reset stream or timeout. Malformed or oversized responses
+// and errors reported by the remote are not retried.
+func IsRetryableRequestError(err error) bool {
+	var chunkErr *ChunkError
+	switch {
+	case errors.Is(err, ErrMalformedChunk),
+		errors.Is(err, ErrChunkTooLarge),
+		errors.Is(err, ErrEmptyResponse),
+		errors.As(err, &chunkErr):
+		return false
+	}
+
+	return true
+}
+
+// RequestWithRetry performs a Request, retrying transient failures according
+// to the policy. Each attempt opens a fresh stream.
+func (n *Host) RequestWithRetry(ctx context.Context, pid peer.ID, protocolID protocol.ID, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler, policy RetryPolicy) error {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryableRequestError
+	}
+
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := n.Request(ctx, pid, protocolID, req, resp)
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		base.Log.Debug("Retrying request", "peer", pid, "protocol", protocolID, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
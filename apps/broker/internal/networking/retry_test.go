@@ -0,0 +1,70 @@
+package networking
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+type malformedItem struct{}
+
+func (malformedItem) UnmarshalBinary([]byte) error {
+	return errors.New("bad item")
+}
+
+func TestRequestWithRetry(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+
+	var attempts atomic.Int64
+	echo := echoHandler(t, 0, nil, nil)
+	server.setStreamHandler(testRequestProtocol, func(s network.Stream) {
+		// Fail the first two attempts.
+		if attempts.Add(1) <= 2 {
+			_ = s.Reset()
+			return
+		}
+		echo(s)
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var resp testItem
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}
+	if err := client.RequestWithRetry(ctx, server.host.ID(), testRequestProtocol, &testItem{"ping"}, &resp, policy); err != nil {
+		t.Fatal(err)
+	}
+	if attempts.Load() != 3 || resp.value != "ping" {
+		t.Errorf("expected success on the third attempt, got %d attempts and %q", attempts.Load(), resp.value)
+	}
+}
+
+func TestRequestWithRetryGivesUpOnMalformedResponse(t *testing.T) {
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{})
+
+	var attempts atomic.Int64
+	echo := echoHandler(t, 0, nil, nil)
+	server.setStreamHandler(testRequestProtocol, func(s network.Stream) {
+		attempts.Add(1)
+		echo(s)
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond}
+	err := client.RequestWithRetry(ctx, server.host.ID(), testRequestProtocol, &testItem{"ping"}, malformedItem{}, policy)
+	if !errors.Is(err, ErrMalformedChunk) {
+		t.Fatalf("expected malformed chunk error, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected no retries for a malformed response, got %d attempts", attempts.Load())
+	}
+}
@@ -0,0 +1,80 @@
+package networking
+
+import (
+	libp2p "github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
+)
+
+// securityTransports are the security protocols we offer on TCP, in order
+// of preference.
+var securityTransports = []struct {
+	id          protocol.ID
+	constructor any
+}{
+	{libp2ptls.ID, libp2ptls.New},
+	{noise.ID, noise.New},
+}
+
+// securityOptions configures the host with securityTransports.
+func securityOptions() libp2p.Option {
+	opts := make([]libp2p.Option, 0, len(securityTransports))
+	for _, st := range securityTransports {
+		opts = append(opts, libp2p.Security(string(st.id), st.constructor))
+	}
+
+	return libp2p.ChainOptions(opts...)
+}
+
+// securityIDs returns the protocol IDs of securityTransports.
+func securityIDs() []string {
+	ids := make([]string, 0, len(securityTransports))
+	for _, st := range securityTransports {
+		ids = append(ids, string(st.id))
+	}
+
+	return ids
+}
+
+// quicSecurity labels QUIC connections. QUIC secures them with its own TLS
+// handshake instead of negotiating a security protocol, so they are kept
+// apart from TCP connections negotiating TLS.
+const quicSecurity = "quic-tls"
+
+// PeerSecurityProtocol returns the security protocol negotiated on the
+// peer's oldest connection, e.g. "/noise" or "/tls/1.0.0", or "quic-tls" for
+// QUIC connections.
+func (n *Host) PeerSecurityProtocol(pid peer.ID) (string, bool) {
+	conn := n.oldestConn(pid)
+	if conn == nil {
+		return "", false
+	}
+
+	return securityOf(conn.ConnState()), true
+}
+
+// SecurityStats counts open connections by negotiated security protocol.
+func (n *Host) SecurityStats() map[string]int {
+	stats := make(map[string]int)
+	for _, conn := range n.host.Network().Conns() {
+		stats[securityOf(conn.ConnState())]++
+	}
+
+	return stats
+}
+
+// securityOf returns the security protocol of a connection, labelling QUIC,
+// which reports no separate security protocol, as quicSecurity.
+func securityOf(state network.ConnectionState) string {
+	switch {
+	case state.Transport == "quic-v1":
+		return quicSecurity
+	case state.Security == "":
+		return "unknown"
+	}
+
+	return string(state.Security)
+}
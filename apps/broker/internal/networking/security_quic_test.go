@@ -0,0 +1,25 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
+)
+
+func TestPeerSecurityProtocolQUIC(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	overTCP := newTestHost(t, &config.Config{})
+	overQUIC := newTestHost(t, &config.Config{QUICPort: freeUDPPort(t)})
+	dialTransport(t, a, overTCP, "tcp")
+	dialTransport(t, a, overQUIC, "quic")
+
+	security, ok := a.PeerSecurityProtocol(overQUIC.host.ID())
+	if !ok || security != quicSecurity {
+		t.Errorf("expected %s, got %q (ok=%v)", quicSecurity, security, ok)
+	}
+	stats := a.SecurityStats()
+	if stats[quicSecurity] != 1 || stats[string(libp2ptls.ID)] != 1 {
+		t.Errorf("expected QUIC and TCP+TLS to be counted apart, got %v", stats)
+	}
+}
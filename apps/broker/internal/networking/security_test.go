@@ -0,0 +1,52 @@
+package networking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
+)
+
+func TestPeerSecurityProtocol(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+	// Dial a single address so that exactly one connection forms.
+	if err := a.host.Connect(context.Background(), loopbackInfo(t, b)); err != nil {
+		t.Fatal(err)
+	}
+
+	// TLS is listed first in the host's security options, so it wins the
+	// negotiation between two of our hosts.
+	security, ok := a.PeerSecurityProtocol(b.host.ID())
+	if !ok || security != string(libp2ptls.ID) {
+		t.Errorf("expected %s, got %q (ok=%v)", libp2ptls.ID, security, ok)
+	}
+	if stats := a.SecurityStats(); stats[string(libp2ptls.ID)] != 1 {
+		t.Errorf("expected one TLS connection, got %v", stats)
+	}
+
+	if _, ok := a.PeerSecurityProtocol(peer.ID("unknown")); ok {
+		t.Error("expected no security protocol for an unconnected peer")
+	}
+}
+
+func TestSecurityOf(t *testing.T) {
+	tests := []struct {
+		state network.ConnectionState
+		want  string
+	}{
+		{network.ConnectionState{Transport: "tcp", Security: libp2ptls.ID}, string(libp2ptls.ID)},
+		{network.ConnectionState{Transport: "tcp", Security: noise.ID}, string(noise.ID)},
+		{network.ConnectionState{Transport: "quic-v1"}, quicSecurity},
+		{network.ConnectionState{Transport: "webtransport"}, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := securityOf(tt.state); got != tt.want {
+			t.Errorf("%+v: expected %s, got %s", tt.state, tt.want, got)
+		}
+	}
+}
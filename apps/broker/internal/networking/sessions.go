@@ -0,0 +1,169 @@
+package networking
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// PeerSession describes how long a currently connected peer has been with us.
+type PeerSession struct {
+	ID          peer.ID
+	ConnectedAt time.Time
+	Duration    time.Duration
+}
+
+// PeerSessions returns a snapshot of all connected peers, longest session first.
+func (n *Host) PeerSessions() []PeerSession {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	now := time.Now()
+	sessions := make([]PeerSession, 0, len(n.connectedAt))
+	for pid, at := range n.connectedAt {
+		sessions = append(sessions, PeerSession{
+			ID:          pid,
+			ConnectedAt: at,
+			Duration:    now.Sub(at),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Duration > sessions[j].Duration
+	})
+
+	return sessions
+}
+
+// Peer age buckets used by PeerAgeHistogram.
+const (
+	ageUnderMinute   = "<1m"
+	ageMinutes       = "1-10m"
+	ageTensOfMinutes = "10-60m"
+	ageOverHour      = ">60m"
+)
+
+// PeerAgeHistogram buckets connected peers by how long they have been
+// connected. All buckets are present, even when empty.
+func (n *Host) PeerAgeHistogram() map[string]int {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	histogram := map[string]int{
+		ageUnderMinute:   0,
+		ageMinutes:       0,
+		ageTensOfMinutes: 0,
+		ageOverHour:      0,
+	}
+	now := time.Now()
+	for _, at := range n.connectedAt {
+		switch age := now.Sub(at); {
+		case age < time.Minute:
+			histogram[ageUnderMinute]++
+		case age < 10*time.Minute:
+			histogram[ageMinutes]++
+		case age < time.Hour:
+			histogram[ageTensOfMinutes]++
+		default:
+			histogram[ageOverHour]++
+		}
+	}
+
+	return histogram
+}
+
+// ConnectedPeerCount returns the number of peers we are connected to.
+func (n *Host) ConnectedPeerCount() int {
+	return len(n.host.Network().Peers())
+}
+
+// notifiee tracks peer connect times so that sessions and connection manager
+// grace periods are measured from the same moment. TestDial probe
+// connections are left out of both callbacks, but closing one still settles
+// a tracked peer whose other connections are already gone.
+func (n *Host) notifiee() network.Notifiee {
+	return &network.NotifyBundle{
+		ConnectedF: func(net network.Network, conn network.Conn) {
+			if n.claimProbeConn(conn) {
+				return
+			}
+			n.peerConnected(conn)
+			n.closeDuplicateConns(net, conn.RemotePeer())
+			n.updateLogVerbosity()
+		},
+		DisconnectedF: func(net network.Network, conn network.Conn) {
+			if n.releaseProbeConn(conn) && !n.isTracked(conn.RemotePeer()) {
+				return
+			}
+			n.peerDisconnected(net, conn)
+			n.updateLogVerbosity()
+		},
+	}
+}
+
+// isTracked reports whether pid has a session in our peer bookkeeping.
+func (n *Host) isTracked(pid peer.ID) bool {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	_, ok := n.connectedAt[pid]
+
+	return ok
+}
+
+func (n *Host) peerConnected(conn network.Conn) {
+	pid := conn.RemotePeer()
+	if conn.Stat().Direction == network.DirOutbound {
+		n.recordDialSuccess(conn.RemoteMultiaddr())
+	}
+	n.recordConnDirection(pid, conn.Stat().Direction)
+
+	n.peersLock.Lock()
+	if _, ok := n.connectedAt[pid]; ok {
+		n.peersLock.Unlock()
+		return
+	}
+	opened := conn.Stat().Opened
+	if opened.IsZero() {
+		opened = time.Now()
+	}
+	n.connectedAt[pid] = opened
+	n.lastActivity[pid] = opened
+	n.recordUptimeConnect(pid, opened)
+	n.peersLock.Unlock()
+
+	n.recordChurn(true)
+	n.recordFirstPeer()
+	n.emitPeerEvent(PeerEvent{Peer: pid, Type: PeerConnected, Direction: conn.Stat().Direction, Time: opened})
+	if onConnect := n.config().OnPeerConnect; onConnect != nil {
+		go onConnect(pid, conn.Stat().Direction)
+	}
+}
+
+func (n *Host) peerDisconnected(net network.Network, conn network.Conn) {
+	pid := conn.RemotePeer()
+	// A peer may still be reachable over another connection.
+	if net.Connectedness(pid) == network.Connected {
+		return
+	}
+
+	n.peersLock.Lock()
+	_, wasConnected := n.connectedAt[pid]
+	delete(n.connectedAt, pid)
+	delete(n.lastActivity, pid)
+	n.recordUptimeDisconnect(pid, time.Now())
+	n.peersLock.Unlock()
+
+	n.forgetObservedAddr(pid)
+	n.forgetLatency(pid)
+	if wasConnected {
+		n.recordChurn(false)
+		n.emitPeerEvent(PeerEvent{Peer: pid, Type: PeerDisconnected, Time: time.Now()})
+	}
+
+	if onDisconnect := n.config().OnPeerDisconnect; onDisconnect != nil && wasConnected {
+		go onDisconnect(pid)
+	}
+}
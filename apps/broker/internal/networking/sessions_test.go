@@ -0,0 +1,93 @@
+package networking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPeerSessions(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+	c := newTestHost(t, &config.Config{})
+
+	connectHosts(t, a, b)
+	time.Sleep(50 * time.Millisecond)
+	connectHosts(t, a, c)
+
+	waitFor(t, func() bool { return len(a.PeerSessions()) == 2 })
+
+	sessions := a.PeerSessions()
+	if sessions[0].ID != b.host.ID() {
+		t.Errorf("expected longest session to be %s, got %s", b.host.ID(), sessions[0].ID)
+	}
+	if sessions[0].Duration < sessions[1].Duration {
+		t.Errorf("sessions not sorted by duration: %v", sessions)
+	}
+
+	_ = a.host.Network().ClosePeer(b.host.ID())
+	waitFor(t, func() bool { return len(a.PeerSessions()) == 1 })
+}
+
+func TestPeerConnectionCallbacks(t *testing.T) {
+	connected := make(chan network.Direction, 1)
+	disconnected := make(chan peer.ID, 1)
+	server := newTestHost(t, &config.Config{
+		OnPeerConnect: func(_ peer.ID, dir network.Direction) {
+			connected <- dir
+		},
+		OnPeerDisconnect: func(pid peer.ID) {
+			disconnected <- pid
+		},
+	})
+	client := newTestHost(t, &config.Config{})
+
+	connectHosts(t, client, server)
+	select {
+	case dir := <-connected:
+		if dir != network.DirInbound {
+			t.Errorf("expected inbound connection, got %s", dir)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connect callback did not fire")
+	}
+
+	_ = client.host.Network().ClosePeer(server.host.ID())
+	select {
+	case pid := <-disconnected:
+		if pid != client.host.ID() {
+			t.Errorf("unexpected disconnected peer %s", pid)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("disconnect callback did not fire")
+	}
+}
+
+func TestPeerAgeHistogram(t *testing.T) {
+	n := NewHost(&config.Config{})
+	now := time.Now()
+	for i, age := range []time.Duration{
+		10 * time.Second,
+		30 * time.Second,
+		5 * time.Minute,
+		30 * time.Minute,
+		2 * time.Hour,
+		48 * time.Hour,
+	} {
+		n.connectedAt[peer.ID(rune('a'+i))] = now.Add(-age)
+	}
+
+	want := map[string]int{"<1m": 2, "1-10m": 1, "10-60m": 1, ">60m": 2}
+	got := n.PeerAgeHistogram()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d buckets, got %v", len(want), got)
+	}
+	for bucket, count := range want {
+		if got[bucket] != count {
+			t.Errorf("bucket %s: expected %d peers, got %d", bucket, count, got[bucket])
+		}
+	}
+}
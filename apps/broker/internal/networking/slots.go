@@ -0,0 +1,93 @@
+package networking
+
+import (
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// slotReservationTimeout is how long a reserved slot is held if the peer
+// never connects.
+const slotReservationTimeout = 30 * time.Second
+
+var ErrNoPeerSlots = errors.New("no peer slots available")
+
+type slotReservation struct {
+	expires time.Time
+}
+
+// ReserveSlot holds a connection slot for pid against MaxPeerCount so that
+// other connections cannot take it before we dial. The slot is freed by the
+// returned release function or after slotReservationTimeout. A peer that is
+// already connected holds a slot anyway and gets no reservation. A live
+// reservation for pid is renewed without counting slots again.
+func (n *Host) ReserveSlot(pid peer.ID) (func(), error) {
+	if n.config().MaxPeerCount <= 0 || n.host.Network().Connectedness(pid) == network.Connected {
+		return func() {}, nil
+	}
+
+	n.slotsLock.Lock()
+	defer n.slotsLock.Unlock()
+
+	if r, ok := n.reservations[pid]; !ok || time.Now().After(r.expires) {
+		if n.usedSlots(pid) >= n.config().MaxPeerCount {
+			return nil, ErrNoPeerSlots
+		}
+	}
+	r := &slotReservation{expires: time.Now().Add(slotReservationTimeout)}
+	n.reservations[pid] = r
+
+	return func() {
+		n.slotsLock.Lock()
+		defer n.slotsLock.Unlock()
+
+		if n.reservations[pid] == r {
+			delete(n.reservations, pid)
+		}
+	}, nil
+}
+
+// hasPeerSlot reports whether a connection to pid fits within MaxPeerCount.
+// Peers that are already connected or hold a live reservation always fit;
+// an expired reservation is dropped.
+func (n *Host) hasPeerSlot(pid peer.ID) bool {
+	if n.config().MaxPeerCount <= 0 {
+		return true
+	}
+	if n.host.Network().Connectedness(pid) == network.Connected {
+		return true
+	}
+
+	n.slotsLock.Lock()
+	defer n.slotsLock.Unlock()
+
+	if r, ok := n.reservations[pid]; ok {
+		if !time.Now().After(r.expires) {
+			return true
+		}
+		delete(n.reservations, pid)
+	}
+
+	return n.usedSlots(pid) < n.config().MaxPeerCount
+}
+
+// usedSlots counts connected peers plus live reservations held for other
+// peers that have not connected yet. Expired reservations are dropped.
+// slotsLock must be held.
+func (n *Host) usedSlots(pid peer.ID) int {
+	now := time.Now()
+	used := len(n.host.Network().Peers())
+	for reserved, r := range n.reservations {
+		if now.After(r.expires) {
+			delete(n.reservations, reserved)
+			continue
+		}
+		if reserved != pid && n.host.Network().Connectedness(reserved) != network.Connected {
+			used++
+		}
+	}
+
+	return used
+}
@@ -0,0 +1,84 @@
+package networking
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+func TestReserveSlot(t *testing.T) {
+	server := newTestHost(t, &config.Config{MaxPeerCount: 1})
+	reserved := newTestHost(t, &config.Config{})
+	other := newTestHost(t, &config.Config{})
+
+	release, err := server.ReserveSlot(reserved.host.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ReserveSlot(other.host.ID()); !errors.Is(err, ErrNoPeerSlots) {
+		t.Fatalf("expected ErrNoPeerSlots, got %v", err)
+	}
+
+	expectRejected(t, other, server)
+
+	release()
+	// other's failed dial is in backoff, so connect from the server side.
+	connectHosts(t, server, other)
+}
+
+func TestExpiredReservationHasNoSlot(t *testing.T) {
+	server := newTestHost(t, &config.Config{MaxPeerCount: 1})
+	connected := newTestHost(t, &config.Config{})
+	reserved := newTestHost(t, &config.Config{})
+	connectHosts(t, server, connected)
+
+	pid := reserved.host.ID()
+	server.slotsLock.Lock()
+	server.reservations[pid] = &slotReservation{expires: time.Now().Add(-time.Second)}
+	server.slotsLock.Unlock()
+
+	if server.hasPeerSlot(pid) {
+		t.Error("expected an expired reservation not to hold a slot")
+	}
+	server.slotsLock.Lock()
+	defer server.slotsLock.Unlock()
+	if _, ok := server.reservations[pid]; ok {
+		t.Error("expected the expired reservation to be dropped")
+	}
+}
+
+func TestReserveSlotForConnectedPeer(t *testing.T) {
+	server := newTestHost(t, &config.Config{MaxPeerCount: 1})
+	connected := newTestHost(t, &config.Config{})
+	connectHosts(t, server, connected)
+
+	release, err := server.ReserveSlot(connected.host.ID())
+	if err != nil {
+		t.Fatalf("expected a connected peer to keep its slot, got %v", err)
+	}
+	release()
+
+	server.slotsLock.Lock()
+	defer server.slotsLock.Unlock()
+	if len(server.reservations) != 0 {
+		t.Errorf("expected no reservation for a connected peer, got %v", server.reservations)
+	}
+}
+
+func TestExpiredReservationIsNotRenewed(t *testing.T) {
+	server := newTestHost(t, &config.Config{MaxPeerCount: 1})
+	connected := newTestHost(t, &config.Config{})
+	reserved := newTestHost(t, &config.Config{})
+	connectHosts(t, server, connected)
+
+	pid := reserved.host.ID()
+	server.slotsLock.Lock()
+	server.reservations[pid] = &slotReservation{expires: time.Now().Add(-time.Second)}
+	server.slotsLock.Unlock()
+
+	if _, err := server.ReserveSlot(pid); !errors.Is(err, ErrNoPeerSlots) {
+		t.Fatalf("expected ErrNoPeerSlots for a full host, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package networking
+
+import (
+	"time"
+)
+
+// TimeToFirstPeer returns how long after Init the first peer connected. It
+// reports false until that happens.
+func (n *Host) TimeToFirstPeer() (time.Duration, bool) {
+	n.startupLock.Lock()
+	defer n.startupLock.Unlock()
+
+	if n.firstPeerAt.IsZero() {
+		return 0, false
+	}
+	return n.firstPeerAt.Sub(n.startedAt), true
+}
+
+func (n *Host) markStarted() {
+	n.startupLock.Lock()
+	defer n.startupLock.Unlock()
+
+	n.startedAt = time.Now()
+}
+
+// recordFirstPeer notes the first connection after startup; later calls are
+// ignored.
+func (n *Host) recordFirstPeer() {
+	n.startupLock.Lock()
+	defer n.startupLock.Unlock()
+
+	if !n.firstPeerAt.IsZero() || n.startedAt.IsZero() {
+		return
+	}
+	n.firstPeerAt = time.Now()
+	timeToFirstPeer.WithLabelValues(n.config().NetworkName).Set(n.firstPeerAt.Sub(n.startedAt).Seconds())
+}
@@ -0,0 +1,36 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+func TestTimeToFirstPeer(t *testing.T) {
+	a := newTestHost(t, &config.Config{NetworkName: "startup-test"})
+	b := newTestHost(t, &config.Config{})
+
+	if _, ok := a.TimeToFirstPeer(); ok {
+		t.Fatal("expected no first peer before connecting")
+	}
+
+	connectHosts(t, a, b)
+	waitFor(t, func() bool {
+		_, ok := a.TimeToFirstPeer()
+		return ok
+	})
+
+	first, _ := a.TimeToFirstPeer()
+	if first <= 0 {
+		t.Errorf("expected a positive time to first peer, got %s", first)
+	}
+	if gaugeValue(t, timeToFirstPeer.WithLabelValues("startup-test")) != first.Seconds() {
+		t.Error("expected the metric to match TimeToFirstPeer")
+	}
+
+	c := newTestHost(t, &config.Config{})
+	connectHosts(t, a, c)
+	if again, _ := a.TimeToFirstPeer(); again != first {
+		t.Errorf("expected time to first peer to stay %s, got %s", first, again)
+	}
+}
@@ -0,0 +1,74 @@
+package networking
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// staticPeerTag protects connected static peers in the connection manager,
+// which exempts them from idle disconnects and connection trimming.
+const staticPeerTag = "static-peer"
+
+// dialInitialPeers dials the static peers and bootnodes in the configured
+// InitialDialOrder.
+func (n *Host) dialInitialPeers() {
+	cfg := n.config()
+	switch cfg.InitialDialOrder {
+	case config.StaticFirst:
+		n.connectToStaticPeers(cfg.StaticPeers)
+		n.connectToBootnodes()
+	case config.BootnodesFirst:
+		n.connectToBootnodes()
+		n.connectToStaticPeers(cfg.StaticPeers)
+	default:
+		go n.connectToStaticPeers(cfg.StaticPeers)
+		n.connectToBootnodes()
+	}
+}
+
+// connectToStaticPeers dials the given static peers concurrently and waits
+// for the dials to finish. Peers that are still configured once connected
+// are protected.
+func (n *Host) connectToStaticPeers(addrs []string) {
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		info, err := peer.AddrInfoFromString(addr)
+		if err != nil {
+			base.Log.Error("Invalid static peer address", "addr", addr, "error", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(n.ctx, bootnodeDialTimeout)
+			defer cancel()
+			if err := n.connectWithPeer(ctx, *info); err == nil && slices.Contains(n.config().StaticPeers, addr) {
+				n.host.ConnManager().Protect(info.ID, staticPeerTag)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// unprotectRemovedPeers lifts tag from the peers that were configured in prev
+// but no longer are in next, unless they remain under another address.
+func (n *Host) unprotectRemovedPeers(prev, next []string, tag string) {
+	remaining := make(map[peer.ID]bool)
+	for _, addr := range next {
+		if info, err := peer.AddrInfoFromString(addr); err == nil {
+			remaining[info.ID] = true
+		}
+	}
+	for _, addr := range addedEntries(next, prev) {
+		if info, err := peer.AddrInfoFromString(addr); err == nil && !remaining[info.ID] {
+			n.host.ConnManager().Unprotect(info.ID, tag)
+		}
+	}
+}
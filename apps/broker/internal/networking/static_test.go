@@ -0,0 +1,43 @@
+package networking
+
+import (
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestInitialDialOrderStaticFirst(t *testing.T) {
+	p2pAddr := func(h *Host) string {
+		addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: h.host.ID(), Addrs: h.host.Addrs()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addrs[0].String()
+	}
+	static := newTestHost(t, &config.Config{})
+	bootnode := newTestHost(t, &config.Config{})
+
+	n := newTestHost(t, &config.Config{
+		StaticPeers:      []string{p2pAddr(static)},
+		Bootnodes:        []string{p2pAddr(bootnode)},
+		InitialDialOrder: config.StaticFirst,
+	})
+	waitFor(t, func() bool { return len(n.BootnodeStatus()) == 1 })
+
+	connectedAt := make(map[peer.ID]PeerSession)
+	for _, s := range n.PeerSessions() {
+		connectedAt[s.ID] = s
+	}
+	staticAt, ok := connectedAt[static.host.ID()]
+	if !ok {
+		t.Fatal("expected the static peer to be connected")
+	}
+	bootnodeAt, ok := connectedAt[bootnode.host.ID()]
+	if !ok {
+		t.Fatal("expected the bootnode to be connected")
+	}
+	if !staticAt.ConnectedAt.Before(bootnodeAt.ConnectedAt) {
+		t.Errorf("expected the static peer to connect first, got %v and %v", staticAt.ConnectedAt, bootnodeAt.ConnectedAt)
+	}
+}
@@ -0,0 +1,125 @@
+package networking
+
+import (
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// newByteLimiter returns a limiter allowing bytesPerSec with one second of
+// burst, or no limit when bytesPerSec is zero.
+func newByteLimiter(bytesPerSec int) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	return rate.NewLimiter(rate.Limit(bytesPerSec), bytesPerSec)
+}
+
+// setByteLimit changes the limit of a limiter from newByteLimiter in place.
+// A limiter that was unlimited starts out with an empty bucket.
+func setByteLimit(limiter *rate.Limiter, bytesPerSec int) {
+	if bytesPerSec <= 0 {
+		limiter.SetLimit(rate.Inf)
+		return
+	}
+
+	limiter.SetLimit(rate.Limit(bytesPerSec))
+	limiter.SetBurst(bytesPerSec)
+}
+
+// throttledStream slows reads and writes down to the host-wide ingress and
+// egress limits. Traffic over the limit waits rather than being dropped, also
+// during graceful shutdown so that handlers can finish their work. It also
+// counts the bytes for the stream bytes metric.
+type throttledStream struct {
+	network.Stream
+	n        *Host
+	bytesIn  prometheus.Counter
+	bytesOut prometheus.Counter
+}
+
+// throttle wraps s with the host's bandwidth limits. Streams are always
+// wrapped, so limits set by a reload also apply to streams already open.
+func (n *Host) throttle(s network.Stream) network.Stream {
+	name := n.config().NetworkName
+	return &throttledStream{
+		Stream:   s,
+		n:        n,
+		bytesIn:  streamBytes.WithLabelValues(name, "in"),
+		bytesOut: streamBytes.WithLabelValues(name, "out"),
+	}
+}
+
+func (s *throttledStream) Read(p []byte) (int, error) {
+	read, err := s.read(p)
+	s.bytesIn.Add(float64(read))
+
+	return read, err
+}
+
+func (s *throttledStream) Write(p []byte) (int, error) {
+	written, err := s.write(p)
+	s.bytesOut.Add(float64(written))
+
+	return written, err
+}
+
+func (s *throttledStream) read(p []byte) (int, error) {
+	limiter := s.n.ingress
+	if limiter.Limit() == rate.Inf {
+		return s.Stream.Read(p)
+	}
+
+	if len(p) > limiter.Burst() {
+		p = p[:max(limiter.Burst(), 1)]
+	}
+	read, err := s.Stream.Read(p)
+	if werr := s.wait(limiter, read); werr != nil && err == nil {
+		err = werr
+	}
+
+	return read, err
+}
+
+func (s *throttledStream) write(p []byte) (int, error) {
+	limiter := s.n.egress
+	if limiter.Limit() == rate.Inf {
+		return s.Stream.Write(p)
+	}
+
+	written := 0
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > limiter.Burst() {
+			chunk = chunk[:max(limiter.Burst(), 1)]
+		}
+		if err := s.wait(limiter, len(chunk)); err != nil {
+			return written, err
+		}
+		w, err := s.Stream.Write(chunk)
+		written += w
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// wait blocks until limiter allows size bytes. A reload may lower the burst
+// while we wait, in which case the rest is waited for in smaller chunks.
+func (s *throttledStream) wait(limiter *rate.Limiter, size int) error {
+	for size > 0 && limiter.Limit() != rate.Inf {
+		chunk := min(size, max(limiter.Burst(), 1))
+		if err := limiter.WaitN(s.n.hardCtx, chunk); err != nil {
+			if s.n.hardCtx.Err() != nil {
+				return err
+			}
+			continue
+		}
+		size -= chunk
+	}
+
+	return nil
+}
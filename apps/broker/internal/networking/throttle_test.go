@@ -0,0 +1,85 @@
+package networking
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestEgressThrottling(t *testing.T) {
+	const rate = 64 << 10
+	server := newTestHost(t, &config.Config{})
+	client := newTestHost(t, &config.Config{MaxEgressBytesPerSec: rate})
+
+	received := make(chan int64, 1)
+	server.setStreamHandler("/test/throttle/1.0.0", func(s network.Stream) {
+		defer s.Close()
+		copied, _ := io.Copy(io.Discard, s)
+		received <- copied
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := client.host.NewStream(ctx, server.host.ID(), "/test/throttle/1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := client.throttle(s)
+
+	// The first second is covered by the burst, the remaining two are not.
+	start := time.Now()
+	if _, err := stream.Write(make([]byte, 3*rate)); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	_ = stream.CloseWrite()
+
+	if elapsed < 1500*time.Millisecond || elapsed > 4*time.Second {
+		t.Errorf("expected writing 3s worth of data to take about 2s, took %s", elapsed)
+	}
+	if got := <-received; got != 3*rate {
+		t.Errorf("expected all %d bytes to arrive, got %d", 3*rate, got)
+	}
+}
+
+func TestThrottledHandlerFinishesDuringGracefulStop(t *testing.T) {
+	const rate = 1 << 10
+	server := newTestHost(t, &config.Config{MaxEgressBytesPerSec: rate})
+	client := newTestHost(t, &config.Config{})
+
+	started := make(chan struct{})
+	written := make(chan error, 1)
+	server.setStreamHandler("/test/throttle/1.0.0", func(s network.Stream) {
+		defer s.Close()
+		close(started)
+		// Beyond the burst, so the write is still throttled once shutdown
+		// begins.
+		_, err := s.Write(make([]byte, 2*rate))
+		written <- err
+	})
+	connectHosts(t, client, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := client.host.NewStream(ctx, server.host.ID(), "/test/throttle/1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if err := server.GracefulStop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("expected the throttled write to finish during graceful stop, got %v", err)
+	}
+}
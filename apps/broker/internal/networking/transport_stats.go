@@ -0,0 +1,74 @@
+package networking
+
+import (
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// TransportStat summarises the connections using one transport. Bytes are
+// those exchanged with currently connected peers, attributed to the
+// transport of each peer's oldest connection. Handshakes count outbound
+// dials since startup.
+type TransportStat struct {
+	Connections          int
+	BytesIn              int64
+	BytesOut             int64
+	Handshakes           int
+	HandshakeFailures    int
+	HandshakeFailureRate float64
+}
+
+// TransportStats returns per-transport connection, traffic and handshake
+// statistics, keyed by "tcp", "quic" or "unknown".
+func (n *Host) TransportStats() map[string]TransportStat {
+	stats := make(map[string]TransportStat)
+
+	for _, conn := range n.host.Network().Conns() {
+		t := transportOf(conn.RemoteMultiaddr())
+		stat := stats[t]
+		stat.Connections++
+		stats[t] = stat
+	}
+
+	for _, pid := range n.host.Network().Peers() {
+		addr, ok := n.PeerConnectedAddr(pid)
+		if !ok {
+			continue
+		}
+		t := transportOf(addr)
+		bw := n.bandwidth.GetBandwidthForPeer(pid)
+		stat := stats[t]
+		stat.BytesIn += bw.TotalIn
+		stat.BytesOut += bw.TotalOut
+		stats[t] = stat
+	}
+
+	n.familiesLock.Lock()
+	for t, dials := range n.transportDials {
+		stat := stats[t]
+		stat.Handshakes = dials.Successes + dials.Failures
+		stat.HandshakeFailures = dials.Failures
+		if stat.Handshakes > 0 {
+			stat.HandshakeFailureRate = float64(dials.Failures) / float64(stat.Handshakes)
+		}
+		stats[t] = stat
+	}
+	n.familiesLock.Unlock()
+
+	return stats
+}
+
+// recordTransportDial counts a dial outcome against the address' transport.
+// familiesLock must be held.
+func (n *Host) recordTransportDial(addr ma.Multiaddr, ok bool) {
+	t := transportOf(addr)
+	stat := n.transportDials[t]
+	result := "success"
+	if ok {
+		stat.Successes++
+	} else {
+		stat.Failures++
+		result = "failure"
+	}
+	n.transportDials[t] = stat
+	transportDials.WithLabelValues(n.config().NetworkName, t, result).Inc()
+}
@@ -0,0 +1,62 @@
+package networking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// dialTransport connects a to b using a single loopback address of one
+// transport, so that exactly one connection forms.
+func dialTransport(t *testing.T, a, b *Host, transport string) {
+	t.Helper()
+
+	var addrs []ma.Multiaddr
+	for _, addr := range b.host.Addrs() {
+		if ip, err := manet.ToIP(addr); err == nil && ip.IsLoopback() && transportOf(addr) == transport {
+			addrs = append(addrs, addr)
+			break
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.connectWithPeer(ctx, peer.AddrInfo{ID: b.host.ID(), Addrs: addrs}); err != nil {
+		t.Fatalf("failed to connect over %s: %v", transport, err)
+	}
+}
+
+func TestTransportStats(t *testing.T) {
+	n := newTestHost(t, &config.Config{QUICPort: freeUDPPort(t)})
+	overTCP := newTestHost(t, &config.Config{})
+	overQUIC := newTestHost(t, &config.Config{QUICPort: freeUDPPort(t)})
+
+	dialTransport(t, n, overTCP, "tcp")
+	dialTransport(t, n, overQUIC, "quic")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.connectWithPeer(ctx, unreachablePeer(t)); err == nil {
+		t.Fatal("expected dialing an unreachable peer to fail")
+	}
+
+	var stats map[string]TransportStat
+	waitFor(t, func() bool {
+		stats = n.TransportStats()
+		return stats["tcp"].BytesOut > 0 && stats["quic"].BytesOut > 0
+	})
+
+	if stats["tcp"].Connections != 1 || stats["quic"].Connections != 1 {
+		t.Errorf("expected one connection per transport, got %+v", stats)
+	}
+	if quic := stats["quic"]; quic.Handshakes != 1 || quic.HandshakeFailures != 0 {
+		t.Errorf("unexpected quic handshakes %+v", quic)
+	}
+	if tcp := stats["tcp"]; tcp.Handshakes != 2 || tcp.HandshakeFailures != 1 || tcp.HandshakeFailureRate != 0.5 {
+		t.Errorf("unexpected tcp handshakes %+v", tcp)
+	}
+}
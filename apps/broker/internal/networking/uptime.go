@@ -0,0 +1,85 @@
+package networking
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// peerUptime accumulates how long a peer has been connected since we first
+// saw it.
+type peerUptime struct {
+	firstSeen      time.Time
+	connected      time.Duration
+	connectedSince time.Time
+	disconnectedAt time.Time
+}
+
+// PeerUptimeRatio returns the fraction of time since the peer was first seen
+// that it has been connected. It reports false for peers with no history.
+func (n *Host) PeerUptimeRatio(pid peer.ID) (float64, bool) {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	u, ok := n.uptimes[pid]
+	if !ok {
+		return 0, false
+	}
+
+	now := time.Now()
+	connected := u.connected
+	if !u.connectedSince.IsZero() {
+		connected += now.Sub(u.connectedSince)
+	}
+	total := now.Sub(u.firstSeen)
+	if total <= 0 {
+		return 1, true
+	}
+
+	return min(float64(connected)/float64(total), 1), true
+}
+
+// recordUptimeConnect starts an interval for pid. peersLock must be held.
+func (n *Host) recordUptimeConnect(pid peer.ID, at time.Time) {
+	u, ok := n.uptimes[pid]
+	if !ok {
+		if len(n.uptimes) >= maxPeerHistory {
+			n.evictUptime()
+		}
+		u = &peerUptime{firstSeen: at}
+		n.uptimes[pid] = u
+	}
+	u.connectedSince = at
+}
+
+// recordUptimeDisconnect closes the open interval for pid. peersLock must be
+// held.
+func (n *Host) recordUptimeDisconnect(pid peer.ID, at time.Time) {
+	u, ok := n.uptimes[pid]
+	if !ok || u.connectedSince.IsZero() {
+		return
+	}
+	u.connected += at.Sub(u.connectedSince)
+	u.connectedSince = time.Time{}
+	u.disconnectedAt = at
+}
+
+// evictUptime forgets the peer that disconnected the longest ago, or when
+// every tracked peer is connected, the one connected the longest, so the
+// history never grows past maxPeerHistory. peersLock must be held.
+func (n *Host) evictUptime() {
+	var stale peer.ID
+	var staleAt time.Time
+	staleConnected := true
+	for pid, u := range n.uptimes {
+		connected := !u.connectedSince.IsZero()
+		at := u.disconnectedAt
+		if connected {
+			at = u.connectedSince
+		}
+		if stale == "" || (staleConnected && !connected) || (connected == staleConnected && at.Before(staleAt)) {
+			stale, staleAt, staleConnected = pid, at, connected
+		}
+	}
+	delete(n.uptimes, stale)
+}
@@ -0,0 +1,98 @@
+package networking
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestPeerUptimeRatio(t *testing.T) {
+	n := NewHost(&config.Config{})
+	pid := peer.ID("flaky")
+
+	if _, ok := n.PeerUptimeRatio(pid); ok {
+		t.Fatal("expected no ratio for an unknown peer")
+	}
+
+	// Connected for 30s, away for 50s, connected again for the last 20s.
+	now := time.Now()
+	n.peersLock.Lock()
+	n.recordUptimeConnect(pid, now.Add(-100*time.Second))
+	n.recordUptimeDisconnect(pid, now.Add(-70*time.Second))
+	n.recordUptimeConnect(pid, now.Add(-20*time.Second))
+	n.peersLock.Unlock()
+
+	ratio, ok := n.PeerUptimeRatio(pid)
+	if !ok {
+		t.Fatal("expected a ratio once the peer has history")
+	}
+	if math.Abs(ratio-0.5) > 0.01 {
+		t.Errorf("expected an uptime ratio of about 0.5, got %f", ratio)
+	}
+}
+
+func TestPeerUptimeRatioTracksConnections(t *testing.T) {
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+
+	connectHosts(t, a, b)
+	waitFor(t, func() bool {
+		_, ok := a.PeerUptimeRatio(b.host.ID())
+		return ok
+	})
+	_ = a.host.Network().ClosePeer(b.host.ID())
+	waitFor(t, func() bool { return a.ConnectedPeerCount() == 0 })
+	time.Sleep(50 * time.Millisecond)
+
+	if ratio, _ := a.PeerUptimeRatio(b.host.ID()); ratio >= 1 {
+		t.Errorf("expected the ratio to drop after disconnecting, got %f", ratio)
+	}
+}
+
+func TestPeerUptimeHistoryIsBounded(t *testing.T) {
+	n := NewHost(&config.Config{})
+	start := time.Now().Add(-time.Hour)
+
+	n.peersLock.Lock()
+	for i := range maxPeerHistory {
+		pid := peer.ID(fmt.Sprintf("peer-%d", i))
+		at := start.Add(time.Duration(i) * time.Second)
+		n.recordUptimeConnect(pid, at)
+		n.recordUptimeDisconnect(pid, at.Add(time.Second))
+	}
+	n.recordUptimeConnect(peer.ID("new"), time.Now())
+	n.peersLock.Unlock()
+
+	if len(n.uptimes) != maxPeerHistory {
+		t.Errorf("expected history for %d peers, got %d", maxPeerHistory, len(n.uptimes))
+	}
+	if _, ok := n.PeerUptimeRatio(peer.ID("peer-0")); ok {
+		t.Error("expected the peer disconnected the longest ago to be forgotten")
+	}
+	if _, ok := n.PeerUptimeRatio(peer.ID("new")); !ok {
+		t.Error("expected the new peer to be tracked")
+	}
+}
+
+func TestPeerUptimeHistoryIsBoundedWhileConnected(t *testing.T) {
+	n := NewHost(&config.Config{})
+	start := time.Now().Add(-time.Hour)
+
+	n.peersLock.Lock()
+	for i := range maxPeerHistory {
+		n.recordUptimeConnect(peer.ID(fmt.Sprintf("peer-%d", i)), start.Add(time.Duration(i)*time.Second))
+	}
+	n.recordUptimeConnect(peer.ID("new"), time.Now())
+	n.peersLock.Unlock()
+
+	if len(n.uptimes) != maxPeerHistory {
+		t.Errorf("expected history for %d peers, got %d", maxPeerHistory, len(n.uptimes))
+	}
+	if _, ok := n.PeerUptimeRatio(peer.ID("peer-0")); ok {
+		t.Error("expected the peer connected the longest to be forgotten")
+	}
+}
@@ -0,0 +1,36 @@
+package networking
+
+import (
+	"log/slog"
+
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+)
+
+// updateLogVerbosity switches dial logging to verbose when the peer count
+// drops below VerboseBelowPeers and back once it recovers.
+func (n *Host) updateLogVerbosity() {
+	threshold := n.config().VerboseBelowPeers
+	if threshold <= 0 {
+		return
+	}
+
+	count := n.ConnectedPeerCount()
+	verbose := count < threshold
+	if n.verboseLogging.Swap(verbose) == verbose {
+		return
+	}
+	if verbose {
+		base.Log.Info("Peer count below threshold, enabling verbose dial logging", "peers", count, "threshold", threshold)
+	} else {
+		base.Log.Info("Peer count recovered, restoring normal dial logging", "peers", count, "threshold", threshold)
+	}
+}
+
+// dialLogLevel is the level dial failures are logged at.
+func (n *Host) dialLogLevel() slog.Level {
+	if n.verboseLogging.Load() {
+		return slog.LevelWarn
+	}
+
+	return slog.LevelDebug
+}
@@ -0,0 +1,25 @@
+package networking
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+)
+
+func TestVerboseLoggingBelowPeerThreshold(t *testing.T) {
+	n := newTestHost(t, &config.Config{VerboseBelowPeers: 2})
+	a := newTestHost(t, &config.Config{})
+	b := newTestHost(t, &config.Config{})
+
+	if n.dialLogLevel() != slog.LevelWarn {
+		t.Fatal("expected verbose dial logging with no peers")
+	}
+
+	connectHosts(t, n, a)
+	connectHosts(t, n, b)
+	waitFor(t, func() bool { return n.dialLogLevel() == slog.LevelDebug })
+
+	_ = n.host.Network().ClosePeer(b.host.ID())
+	waitFor(t, func() bool { return n.dialLogLevel() == slog.LevelWarn })
+}
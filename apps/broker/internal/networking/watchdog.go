@@ -0,0 +1,76 @@
+package networking
+
+import (
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/flinkcoin/mono/libs/shared/pkg/base"
+)
+
+// maxIsolationRestarts is how many restarts may fail to get us out of
+// isolation before it is reported as an error.
+const maxIsolationRestarts = 3
+
+// IsolationRestarts returns how many times the isolation watchdog restarted
+// discovery or re-dialed the bootnodes because the host had no peers. Rounds
+// in which no source could be restarted are not counted.
+func (n *Host) IsolationRestarts() int64 {
+	return n.isolationRestarts.Load()
+}
+
+// isolationWatchdog restarts discovery and re-dials the bootnodes when the
+// host has had no peers for longer than the isolation timeout. While there
+// is neither a bootnode nor a discovery source to retry it does nothing, so
+// bootnodes added by a reload are picked up. A reload that changes the
+// timeout starts the isolation period over.
+func (n *Host) isolationWatchdog() {
+	timeout := n.config().IsolationTimeout
+	isolatedSince := time.Now()
+	restarts := 0
+	for n.waitInterval(func(cfg *config.Config) time.Duration { return sweepInterval(cfg.IsolationTimeout) }) {
+		if next := n.config().IsolationTimeout; next != timeout {
+			timeout = next
+			isolatedSince = time.Now()
+			restarts = 0
+		}
+		if timeout <= 0 {
+			continue
+		}
+
+		if n.ConnectedPeerCount() > 0 || !n.hasPeerSources() {
+			isolatedSince = time.Now()
+			restarts = 0
+			continue
+		}
+		if time.Since(isolatedSince) < timeout {
+			continue
+		}
+
+		restarted := n.RestartDiscovery()
+		redial := len(n.config().Bootnodes) > 0
+		if !restarted && !redial {
+			base.Log.Warn("Host has no peers and no peer source could be restarted", "timeout", timeout)
+			isolatedSince = time.Now()
+			continue
+		}
+
+		restarts++
+		if restarts >= maxIsolationRestarts {
+			base.Log.Error("Host remains isolated after restarting discovery", "attempts", restarts, "since", isolatedSince)
+		} else {
+			base.Log.Warn("Host has no peers, restarting discovery and re-dialing bootnodes", "timeout", timeout)
+		}
+		n.isolationRestarts.Add(1)
+		if redial {
+			n.connectToBootnodes()
+		}
+		isolatedSince = time.Now()
+	}
+}
+
+// hasPeerSources reports whether the watchdog has anything to retry: the
+// bootnodes, the peer registry or a discovery backend.
+func (n *Host) hasPeerSources() bool {
+	cfg := n.config()
+	return len(cfg.Bootnodes) > 0 || cfg.RegistryURL != "" || cfg.DiscoveryBackend != nil
+}
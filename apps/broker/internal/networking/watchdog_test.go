@@ -0,0 +1,83 @@
+package networking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flinkcoin/mono/apps/broker/internal/config"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestIsolationWatchdogRedialsBootnodes(t *testing.T) {
+	bootnode := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: bootnode.host.ID(), Addrs: bootnode.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := newTestHost(t, &config.Config{
+		Bootnodes:        []string{addrs[0].String()},
+		IsolationTimeout: 200 * time.Millisecond,
+	})
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+
+	// Isolate the host and expect the watchdog to bring the bootnode back.
+	_ = n.host.Network().ClosePeer(bootnode.host.ID())
+	waitFor(t, func() bool { return n.IsolationRestarts() > 0 })
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+}
+
+func TestIsolationWatchdogNeedsBootnodes(t *testing.T) {
+	n := newTestHost(t, &config.Config{IsolationTimeout: time.Nanosecond})
+
+	time.Sleep(100 * time.Millisecond)
+	if restarts := n.IsolationRestarts(); restarts != 0 {
+		t.Errorf("expected no restarts without bootnodes, got %d", restarts)
+	}
+}
+
+func TestIsolationWatchdogPicksUpReloadedBootnodes(t *testing.T) {
+	bootnode := newTestHost(t, &config.Config{})
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: bootnode.host.ID(), Addrs: bootnode.host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := newTestHost(t, &config.Config{IsolationTimeout: 200 * time.Millisecond})
+	if err := n.ReloadConfig(&config.Config{IsolationTimeout: 200 * time.Millisecond, Bootnodes: []string{addrs[0].String()}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+
+	_ = n.host.Network().ClosePeer(bootnode.host.ID())
+	waitFor(t, func() bool { return n.IsolationRestarts() > 0 })
+	waitFor(t, func() bool { return n.ConnectedPeerCount() == 1 })
+}
+
+func TestIsolationWatchdogRestartsDiscovery(t *testing.T) {
+	backend := &fakeDiscovery{peers: make(chan peer.AddrInfo)}
+	n := newTestHost(t, &config.Config{
+		DiscoveryBackend: backend,
+		IsolationTimeout: 100 * time.Millisecond,
+	})
+
+	waitFor(t, func() bool { return n.IsolationRestarts() > 0 && backend.starts.Load() > 1 })
+	if !backend.stopped.Load() {
+		t.Error("expected the backend to be stopped before it was restarted")
+	}
+}
+
+func TestIsolationWatchdogRetriesFailedDiscoveryStart(t *testing.T) {
+	backend := &fakeDiscovery{peers: make(chan peer.AddrInfo)}
+	backend.failStarts.Store(1)
+	n := newTestHost(t, &config.Config{
+		DiscoveryBackend: backend,
+		IsolationTimeout: 100 * time.Millisecond,
+	})
+
+	// The failed start must not end discovery: every counted restart starts
+	// the backend again.
+	waitFor(t, func() bool { return n.IsolationRestarts() >= 2 })
+	restarts := n.IsolationRestarts()
+	waitFor(t, func() bool { return backend.starts.Load() > restarts })
+}